@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/b4nst/clef/internal/config"
+)
+
+type Cache struct {
+	Clear CacheClear `cmd:"" help:"Drop cached secrets."`
+}
+
+type CacheClear struct {
+	Stores []string `arg:"" help:"Stores to clear, all cached stores if empty" optional:""`
+}
+
+func (c *CacheClear) Run(ctx context.Context, conf *config.Config) error {
+	if conf == nil {
+		return fmt.Errorf("unexpected nil config")
+	}
+	return conf.ClearCache(ctx, c.Stores...)
+}
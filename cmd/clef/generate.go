@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alecthomas/kong"
+
+	"github.com/b4nst/clef/internal/backend"
+	"github.com/b4nst/clef/internal/config"
+	"github.com/b4nst/clef/internal/generate"
+)
+
+type Generate struct {
+	Store string `help:"Store to store to" short:"s" default:"default"`
+	Key   string `help:"Key to store to" short:"k" required:""`
+
+	Type      string `help:"Kind of secret: password, passphrase, hex or base64 random bytes, uuid, or an ssh (ed25519) or age key pair" short:"t" enum:"password,passphrase,hex,base64,uuid,ssh,age" default:"password"`
+	Length    int    `help:"Length of passwords in characters (32), of passphrases in words (6), or of random bytes (32)" short:"l"`
+	Charset   string `help:"Characters of passwords: alnum, alpha, digits, symbols, or the characters themselves" default:"alnum"`
+	Wordlist  string `help:"Passphrase words file, one per line, defaults to the BIP39 English list" type:"existingfile"`
+	Separator string `help:"Separator of passphrase words" default:"-"`
+	Comment   string `help:"Comment of ssh keys"`
+
+	Print bool `help:"Print the generated secret"`
+	Force bool `help:"Overwrite the key if it already exists" short:"f"`
+}
+
+func (g *Generate) Run(ctx context.Context, ktx *kong.Context, conf *config.Config) error {
+	if conf == nil {
+		return fmt.Errorf("unexpected nil config")
+	}
+
+	store, err := conf.Backend(ctx, g.Store)
+	if err != nil {
+		return fmt.Errorf("could not load store: %w", err)
+	}
+
+	// Don't lose a secret to a typo, or to a store failing to tell whether it has the key
+	if !g.Force {
+		_, err := backend.GetBytes(ctx, backend.Uncached(store), g.Key)
+		switch {
+		case err == nil:
+			return fmt.Errorf("%s already exists in %s store, use --force to overwrite it", g.Key, g.Store)
+		case !errors.Is(err, backend.ErrKeyNotFound):
+			return fmt.Errorf("could not check that %s is new in %s store, use --force to overwrite it: %w", g.Key, g.Store, err)
+		}
+	}
+
+	secret, public, err := g.generate()
+	if err != nil {
+		return fmt.Errorf("generate %s: %w", g.Type, err)
+	}
+
+	if err := backend.SetBytes(ctx, store, g.Key, secret); err != nil {
+		return fmt.Errorf("error settings %s to %s store: %w", g.Key, g.Store, err)
+	}
+
+	fmt.Fprintln(ktx.Stdout, g.Key+" generated")
+	if public != "" {
+		fmt.Fprintln(ktx.Stdout, public)
+	}
+	if g.Print {
+		fmt.Fprintln(ktx.Stdout, strings.TrimSuffix(string(secret), "\n"))
+	}
+	return nil
+}
+
+// generate returns a new secret, and the public key of key pairs.
+func (g *Generate) generate() ([]byte, string, error) {
+	length := func(def int) int {
+		if g.Length != 0 {
+			return g.Length
+		}
+		return def
+	}
+
+	switch g.Type {
+	case "passphrase":
+		words := generate.DefaultWordlist
+		if g.Wordlist != "" {
+			f, err := os.Open(g.Wordlist)
+			if err != nil {
+				return nil, "", err
+			}
+			defer f.Close()
+			if words, err = generate.ReadWordlist(f); err != nil {
+				return nil, "", fmt.Errorf("read %s: %w", g.Wordlist, err)
+			}
+		}
+		p, err := generate.Passphrase(length(6), words, g.Separator)
+		return []byte(p), "", err
+	case "hex", "base64":
+		b, err := generate.Bytes(length(32))
+		if err != nil {
+			return nil, "", err
+		}
+		if g.Type == "hex" {
+			return []byte(hex.EncodeToString(b)), "", nil
+		}
+		return []byte(base64.StdEncoding.EncodeToString(b)), "", nil
+	case "uuid":
+		id, err := generate.UUID()
+		return []byte(id), "", err
+	case "ssh":
+		return generate.SSHKey(g.Comment)
+	case "age":
+		identity, recipient, err := generate.AgeKey()
+		return []byte(identity), recipient, err
+	default:
+		p, err := generate.Password(length(32), g.Charset)
+		return []byte(p), "", err
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/alecthomas/kong"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+
+	"github.com/b4nst/clef/internal/config"
+)
+
+func TestGenerate_Run(t *testing.T) {
+	keyring.MockInit()
+	ctx := context.TODO()
+	conf, err := config.Parse(`
+default_store = "keys"
+
+[stores.keys]
+type = "osstore"
+`)
+	require.NoError(t, err)
+	var stdout bytes.Buffer
+	ktx := &kong.Context{Kong: &kong.Kong{Stdout: &stdout, Stderr: &stdout}}
+
+	g := &Generate{Store: "default", Key: "token", Type: "uuid"}
+	require.NoError(t, g.Run(ctx, ktx, conf), "missing keys are generated")
+	store, err := conf.Backend(ctx, "keys")
+	require.NoError(t, err)
+	first, err := store.Get(ctx, "token")
+	require.NoError(t, err)
+
+	assert.EqualError(t, g.Run(ctx, ktx, conf), "token already exists in default store, use --force to overwrite it")
+
+	g.Force = true
+	require.NoError(t, g.Run(ctx, ktx, conf))
+	second, err := store.Get(ctx, "token")
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+}
@@ -3,15 +3,24 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/alecthomas/kong"
 
+	"github.com/b4nst/clef/internal/backend"
 	"github.com/b4nst/clef/internal/config"
+	"github.com/b4nst/clef/internal/profile"
 )
 
 type Get struct {
 	Store string `help:"Store to lookup from" short:"s" default:"default"`
 	Key   string `arg:"" help:"Key to lookup"`
+
+	Version  string `help:"Version of the secret, for stores keeping versions"`
+	Field    string `help:"Field to extract from a JSON secret, as a dotted path or a JSON pointer" short:"f"`
+	Encoding string `help:"Encode the value, as base64, base64url or hex" enum:"base64,base64url,hex," default:""`
+	Output   string `help:"Write the value to a file, byte for byte, instead of printing it" short:"o" type:"path"`
+	NoCache  bool   `help:"Read from the store, bypassing its cache"`
 }
 
 func (g *Get) Run(ctx context.Context, ktx *kong.Context, conf *config.Config) error {
@@ -23,12 +32,25 @@ func (g *Get) Run(ctx context.Context, ktx *kong.Context, conf *config.Config) e
 	if err != nil {
 		return fmt.Errorf("could not load store: %w", err)
 	}
+	if g.NoCache {
+		store = backend.Uncached(store)
+	}
 
-	v, err := store.Get(ctx, g.Key)
+	secret := profile.Secret{Key: g.Key, Version: g.Version, Field: g.Field, Encoding: g.Encoding}
+	v, err := secret.Fetch(ctx, store)
 	if err != nil {
 		return fmt.Errorf("error getting %s from %s store: %w", g.Key, g.Store, err)
 	}
+	if v, err = secret.Format(v); err != nil {
+		return fmt.Errorf("error getting %s from %s store: %w", g.Key, g.Store, err)
+	}
 
+	if g.Output != "" {
+		if err := os.WriteFile(g.Output, []byte(v), 0o600); err != nil {
+			return fmt.Errorf("write %s: %w", g.Output, err)
+		}
+		return nil
+	}
 	fmt.Fprintln(ktx.Stdout, v)
 	return nil
 }
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alecthomas/kong"
+
+	"github.com/b4nst/clef/internal/backend"
+	"github.com/b4nst/clef/internal/config"
+)
+
+type History struct {
+	Store string `help:"Store to lookup from" short:"s" default:"default"`
+	Key   string `arg:"" help:"Key to show the versions of"`
+}
+
+func (h *History) Run(ctx context.Context, ktx *kong.Context, conf *config.Config) error {
+	if conf == nil {
+		return fmt.Errorf("unexpected nil config")
+	}
+
+	store, err := conf.Backend(ctx, h.Store)
+	if err != nil {
+		return fmt.Errorf("could not load store: %w", err)
+	}
+
+	versioned, ok := store.(backend.Versioned)
+	if !ok {
+		return fmt.Errorf("%s store does not keep versions", h.Store)
+	}
+
+	versions, err := versioned.History(ctx, h.Key)
+	if err != nil {
+		return fmt.Errorf("error getting history of %s from %s store: %w", h.Key, h.Store, err)
+	}
+
+	w := tabwriter.NewWriter(ktx.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tVERSION\tCREATED\tLABELS")
+	for _, v := range versions {
+		current := ""
+		if v.Current {
+			current = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", current, v.ID, v.Created.Local().Format(time.DateTime), strings.Join(v.Labels, ","))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alecthomas/kong"
+
+	"github.com/b4nst/clef/internal/backend"
+	"github.com/b4nst/clef/internal/config"
+)
+
+type List struct {
+	Store  string `help:"Store to list from" short:"s" default:"default"`
+	Prefix string `arg:"" help:"Only list keys starting with prefix" optional:""`
+}
+
+func (l *List) Run(ctx context.Context, ktx *kong.Context, conf *config.Config) error {
+	if conf == nil {
+		return fmt.Errorf("unexpected nil config")
+	}
+
+	store, err := conf.Backend(ctx, l.Store)
+	if err != nil {
+		return fmt.Errorf("could not load store: %w", err)
+	}
+
+	lister, ok := store.(backend.Lister)
+	if !ok {
+		return fmt.Errorf("%s store does not support listing", l.Store)
+	}
+
+	keys, err := lister.List(ctx, l.Prefix)
+	if err != nil {
+		return fmt.Errorf("error listing %s store: %w", l.Store, err)
+	}
+
+	for _, k := range keys {
+		fmt.Fprintln(ktx.Stdout, k)
+	}
+	return nil
+}
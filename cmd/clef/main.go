@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/adrg/xdg"
@@ -11,15 +12,23 @@ import (
 )
 
 type CLI struct {
-	Get     Get     `cmd:"" help:"Lookup a key in a store." aliases:"fetch"`
-	Set     Set     `cmd:"" help:"Store a key value pair." aliases:"put, store"`
-	Delete  Delete  `cmd:"" help:"Delete a key from a store." aliases:"rm"`
-	Version Version `cmd:"" help:"Print app version."`
-	Config  Config  `cmd:"" help:"Manage clef configuration."`
-	Shell   Shell   `cmd:"" help:"Load a shell with secrets injected as env variable."`
-	Exec    Exec    `cmd:"" help:"Execute a command with secrets injected as env variable."`
+	Get      Get      `cmd:"" help:"Lookup a key in a store." aliases:"fetch"`
+	Set      Set      `cmd:"" help:"Store a key value pair." aliases:"put, store"`
+	Generate Generate `cmd:"" help:"Generate a random secret into a store." aliases:"gen"`
+	Delete   Delete   `cmd:"" help:"Delete a key from a store." aliases:"rm"`
+	List     List     `cmd:"" help:"List the keys of a store." aliases:"ls"`
+	History  History  `cmd:"" help:"Show the versions of a key."`
+	Rollback Rollback `cmd:"" help:"Restore a previous version of a key."`
+	Cache    Cache    `cmd:"" help:"Manage the secret cache."`
+	Version  Version  `cmd:"" help:"Print app version."`
+	Config   Config   `cmd:"" help:"Manage clef configuration."`
+	Shell    Shell    `cmd:"" help:"Load a shell with secrets injected as env variable."`
+	Exec     Exec     `cmd:"" help:"Execute a command with secrets injected as env variable."`
 
 	ConfigFile string `help:"Config file" short:"c" default:"${config_file}"`
+
+	// conf is the loaded config, closed on exit
+	conf *config.Config
 }
 
 func ConfigProvider(cli *CLI) (*config.Config, error) {
@@ -27,6 +36,7 @@ func ConfigProvider(cli *CLI) (*config.Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("load config: %w", err)
 	}
+	cli.conf = conf
 	return conf, nil
 }
 
@@ -41,5 +51,9 @@ func main() {
 		kong.BindTo(context.Background(), (*context.Context)(nil)),
 	)
 
-	cmd.FatalIfErrorf(cmd.Run())
+	err := cmd.Run()
+	if cli.conf != nil {
+		err = errors.Join(err, cli.conf.Close())
+	}
+	cmd.FatalIfErrorf(err)
 }
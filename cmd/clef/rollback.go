@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alecthomas/kong"
+
+	"github.com/b4nst/clef/internal/backend"
+	"github.com/b4nst/clef/internal/config"
+)
+
+type Rollback struct {
+	Store string `help:"Store to lookup from" short:"s" default:"default"`
+	Key   string `arg:"" help:"Key to roll back"`
+	To    string `help:"Version to restore, as listed by clef history" required:""`
+}
+
+func (r *Rollback) Run(ctx context.Context, ktx *kong.Context, conf *config.Config) error {
+	if conf == nil {
+		return fmt.Errorf("unexpected nil config")
+	}
+
+	store, err := conf.Backend(ctx, r.Store)
+	if err != nil {
+		return fmt.Errorf("load store: %w", err)
+	}
+
+	versioned, ok := store.(backend.Versioned)
+	if !ok {
+		return fmt.Errorf("%s store does not keep versions", r.Store)
+	}
+
+	if err := versioned.Rollback(ctx, r.Key, r.To); err != nil {
+		return fmt.Errorf("roll back %s of %s store: %w", r.Key, r.Store, err)
+	}
+
+	fmt.Fprintln(ktx.Stdout, r.Key, "rolled back to", r.To)
+
+	return nil
+}
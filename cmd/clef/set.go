@@ -1,19 +1,33 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
 	"github.com/alecthomas/kong"
+	"golang.org/x/term"
 
+	"github.com/b4nst/clef/internal/backend"
 	"github.com/b4nst/clef/internal/config"
 )
 
 type Set struct {
-	Store string   `help:"Store to store to" short:"s" default:"default"`
-	Key   string   `help:"Key to store to" short:"k" required:""`
-	Value []string `arg:"" help:"Value to store."`
+	Store    string   `help:"Store to store to" short:"s" default:"default"`
+	Key      string   `help:"Key to store to" short:"k" required:""`
+	Value    []string `arg:"" help:"Value to store. Read from stdin when piped, or prompted if omitted." optional:""`
+	FromFile string   `help:"Read the value from a file, byte for byte" type:"existingfile"`
+}
+
+func (s *Set) Validate() error {
+	if len(s.Value) > 0 && s.FromFile != "" {
+		return errors.New("expected either <value> or --from-file, not both")
+	}
+	return nil
 }
 
 func (s *Set) Run(ctx context.Context, ktx *kong.Context, conf *config.Config) error {
@@ -21,16 +35,65 @@ func (s *Set) Run(ctx context.Context, ktx *kong.Context, conf *config.Config) e
 		return fmt.Errorf("unexpected nil config")
 	}
 
+	v, err := s.value(ktx)
+	if err != nil {
+		return err
+	}
+
 	store, err := conf.Backend(ctx, s.Store)
 	if err != nil {
 		return fmt.Errorf("could not load store: %w", err)
 	}
 
-	v := strings.Join(s.Value, " ")
-	if err := store.Set(ctx, s.Key, v); err != nil {
+	if err := backend.SetBytes(ctx, store, s.Key, v); err != nil {
 		return fmt.Errorf("error settings %s to %s store: %w", s.Key, s.Store, err)
 	}
 
 	fmt.Fprintln(ktx.Stdout, s.Key+" set")
 	return nil
 }
+
+// value returns the value to store, from the file, the arguments, stdin when it is not a terminal,
+// or prompted on the terminal.
+func (s *Set) value(ktx *kong.Context) ([]byte, error) {
+	switch {
+	case s.FromFile != "":
+		v, err := os.ReadFile(s.FromFile)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", s.FromFile, err)
+		}
+		return v, nil
+	case len(s.Value) > 0:
+		fmt.Fprintln(ktx.Stderr, "warning: values passed as arguments end up in the shell history and the process list, pipe them or omit them to be prompted")
+		return []byte(strings.Join(s.Value, " ")), nil
+	case !term.IsTerminal(int(os.Stdin.Fd())):
+		v, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("read stdin: %w", err)
+		}
+		// Drop the line break of echo and friends, --from-file keeps it
+		v = bytes.TrimSuffix(bytes.TrimSuffix(v, []byte("\n")), []byte("\r"))
+		if len(v) == 0 {
+			return nil, errors.New("empty value on stdin")
+		}
+		return v, nil
+	default:
+		return promptValue(s.Key)
+	}
+}
+
+// promptValue reads the value of key from the terminal twice, without echo.
+func promptValue(key string) ([]byte, error) {
+	v, err := backend.Prompt(fmt.Sprintf("Value for %s: ", key))
+	if err != nil {
+		return nil, err
+	}
+	confirm, err := backend.Prompt("Confirm value: ")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(v, confirm) {
+		return nil, errors.New("values don't match")
+	}
+	return v, nil
+}
@@ -10,7 +10,7 @@ import (
 
 type Shell struct {
 	Profile string           `help:"Profile to load." short:"p" optional:""`
-	Secret  []profile.Secret `help:"Additional secrets to load into the env. Format [store.]secret[=env]. If store is empty, default store will be used. If env is empty, secret name will be used as env name." short:"s" optional:""`
+	Secret  []profile.Secret `help:"Additional secrets to load into the env. Format [store.]secret[@version][#field][=env]. If store is empty, default store will be used. If env is empty, secret name will be used as env name." short:"s" optional:""`
 	Shell   string           `help:"Shell to use" env:"SHELL"`
 }
 
@@ -0,0 +1,52 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// lockFile takes an exclusive advisory lock for filename, blocking until it is available.
+// The lock is held on a sidecar file, as filename itself is replaced on each write.
+func lockFile(filename string) (unlock func() error, err error) {
+	return lockPath(filename + ".lock")
+}
+
+// writeFileAtomic replaces the content of filename with data.
+// Data is written to a temporary file in the same directory, synced, then renamed over filename,
+// so readers either see the previous content or the new one, never a partial write.
+// The permissions of an existing file are preserved.
+func writeFileAtomic(filename string, data []byte) error {
+	perm := os.FileMode(0600)
+	if info, err := os.Stat(filename); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	dir := filepath.Dir(filename)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	// No-op once renamed
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
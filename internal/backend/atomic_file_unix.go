@@ -0,0 +1,37 @@
+//go:build unix
+
+package backend
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockPath takes an exclusive advisory lock on the file lockname, creating it if needed,
+// blocking until it is available.
+func lockPath(lockname string) (unlock func() error, err error) {
+	fd, err := os.OpenFile(lockname, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("open lock: %w", err)
+	}
+	if err := syscall.Flock(int(fd.Fd()), syscall.LOCK_EX); err != nil {
+		fd.Close()
+		return nil, fmt.Errorf("lock %s: %w", lockname, err)
+	}
+
+	return func() error {
+		defer fd.Close()
+		return syscall.Flock(int(fd.Fd()), syscall.LOCK_UN)
+	}, nil
+}
+
+// syncDir flushes a directory entry change, like a rename, to disk.
+func syncDir(dir string) error {
+	fd, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	return fd.Sync()
+}
@@ -0,0 +1,34 @@
+//go:build windows
+
+package backend
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockPath takes an exclusive advisory lock on the file lockname, creating it if needed,
+// blocking until it is available.
+func lockPath(lockname string) (unlock func() error, err error) {
+	fd, err := os.OpenFile(lockname, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("open lock: %w", err)
+	}
+	ol := new(windows.Overlapped)
+	if err := windows.LockFileEx(windows.Handle(fd.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, ol); err != nil {
+		fd.Close()
+		return nil, fmt.Errorf("lock %s: %w", lockname, err)
+	}
+
+	return func() error {
+		defer fd.Close()
+		return windows.UnlockFileEx(windows.Handle(fd.Fd()), 0, 1, 0, ol)
+	}, nil
+}
+
+// syncDir is a no-op on Windows, where directories cannot be synced.
+func syncDir(string) error {
+	return nil
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -32,9 +35,13 @@ func (ab *AWSStoreBuilder) Build(ctx context.Context, name string) (Store, error
 // AWSSecretsManagerClient defines the interface for AWS Secrets Manager operations.
 type AWSSecretsManagerClient interface {
 	GetSecretValue(context.Context, *secretsmanager.GetSecretValueInput, ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+	BatchGetSecretValue(context.Context, *secretsmanager.BatchGetSecretValueInput, ...func(*secretsmanager.Options)) (*secretsmanager.BatchGetSecretValueOutput, error)
 	CreateSecret(context.Context, *secretsmanager.CreateSecretInput, ...func(*secretsmanager.Options)) (*secretsmanager.CreateSecretOutput, error)
 	PutSecretValue(context.Context, *secretsmanager.PutSecretValueInput, ...func(*secretsmanager.Options)) (*secretsmanager.PutSecretValueOutput, error)
 	DeleteSecret(context.Context, *secretsmanager.DeleteSecretInput, ...func(*secretsmanager.Options)) (*secretsmanager.DeleteSecretOutput, error)
+	ListSecrets(context.Context, *secretsmanager.ListSecretsInput, ...func(*secretsmanager.Options)) (*secretsmanager.ListSecretsOutput, error)
+	ListSecretVersionIds(context.Context, *secretsmanager.ListSecretVersionIdsInput, ...func(*secretsmanager.Options)) (*secretsmanager.ListSecretVersionIdsOutput, error)
+	UpdateSecretVersionStage(context.Context, *secretsmanager.UpdateSecretVersionStageInput, ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretVersionStageOutput, error)
 }
 
 // AWSStore represents an AWS Secrets Manager store.
@@ -73,31 +80,252 @@ func NewAWSStore(ctx context.Context, builder *AWSStoreBuilder) (*AWSStore, erro
 
 // Get implements the Store.Get method.
 func (a *AWSStore) Get(ctx context.Context, key string) (string, error) {
+	return a.getString(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(key),
+	})
+}
+
+// GetBytes implements the ByteStore.GetBytes method, returning binary and string secrets alike.
+func (a *AWSStore) GetBytes(ctx context.Context, key string) ([]byte, error) {
+	result, err := a.getSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.SecretString != nil {
+		return []byte(*result.SecretString), nil
+	}
+	return result.SecretBinary, nil
+}
+
+// GetVersion implements the VersionGetter.GetVersion method.
+// The version is a version id, or a staging label like AWSPREVIOUS.
+func (a *AWSStore) GetVersion(ctx context.Context, key, version string) (string, error) {
 	input := &secretsmanager.GetSecretValueInput{
 		SecretId: aws.String(key),
 	}
-	
+	if isAWSVersionID(version) {
+		input.VersionId = aws.String(version)
+	} else {
+		input.VersionStage = aws.String(version)
+	}
+	return a.getString(ctx, input)
+}
+
+// isAWSVersionID reports whether version is a version id rather than a staging label.
+// Version ids generated by AWS are UUIDs.
+func isAWSVersionID(version string) bool {
+	if len(version) != 36 {
+		return false
+	}
+	for i, r := range version {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				return false
+			}
+		default:
+			if !('0' <= r && r <= '9' || 'a' <= r && r <= 'f' || 'A' <= r && r <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (a *AWSStore) getSecretValue(ctx context.Context, input *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
 	result, err := a.client.GetSecretValue(ctx, input)
 	if err != nil {
 		// Check if the error is a ResourceNotFoundException
 		var rnfe *types.ResourceNotFoundException
 		if errors.As(err, &rnfe) {
-			return "", ErrKeyNotFound
+			return nil, ErrKeyNotFound
 		}
-		return "", fmt.Errorf("get aws secret: %w", err)
+		return nil, fmt.Errorf("get aws secret: %w", err)
 	}
-	
+	return result, nil
+}
+
+func (a *AWSStore) getString(ctx context.Context, input *secretsmanager.GetSecretValueInput) (string, error) {
+	result, err := a.getSecretValue(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
 	// Return the secret string
 	if result.SecretString != nil {
 		return *result.SecretString, nil
 	}
-	
-	// If the secret is binary, we don't support it
-	return "", fmt.Errorf("aws secret is binary, not string")
+
+	// Binary secrets are read with GetBytes
+	return "", ErrBinary
+}
+
+// awsCurrentStage is the staging label of the current version of a secret.
+const awsCurrentStage = "AWSCURRENT"
+
+// History implements the Versioned.History method.
+// Versions are labeled with their staging labels, versions without label being deprecated.
+func (a *AWSStore) History(ctx context.Context, key string) ([]Version, error) {
+	input := &secretsmanager.ListSecretVersionIdsInput{
+		SecretId:          aws.String(key),
+		IncludeDeprecated: aws.Bool(true),
+	}
+	var history []Version
+	for {
+		out, err := a.client.ListSecretVersionIds(ctx, input)
+		if err != nil {
+			var rnfe *types.ResourceNotFoundException
+			if errors.As(err, &rnfe) {
+				return nil, ErrKeyNotFound
+			}
+			return nil, fmt.Errorf("list aws secret versions: %w", err)
+		}
+		for _, v := range out.Versions {
+			history = append(history, Version{
+				ID:      aws.ToString(v.VersionId),
+				Created: aws.ToTime(v.CreatedDate),
+				Current: slices.Contains(v.VersionStages, awsCurrentStage),
+				Labels:  v.VersionStages,
+			})
+		}
+		if out.NextToken == nil {
+			break
+		}
+		input.NextToken = out.NextToken
+	}
+	slices.SortStableFunc(history, func(a, b Version) int { return b.Created.Compare(a.Created) })
+	return history, nil
+}
+
+// Rollback implements the Versioned.Rollback method, moving the AWSCURRENT staging label to version,
+// a version id or a staging label. AWS then labels the replaced version AWSPREVIOUS.
+func (a *AWSStore) Rollback(ctx context.Context, key, version string) error {
+	history, err := a.History(ctx, key)
+	if err != nil {
+		return err
+	}
+	var current, target string
+	for _, v := range history {
+		if v.Current {
+			current = v.ID
+		}
+		if target == "" && (v.ID == version || slices.Contains(v.Labels, version)) {
+			target = v.ID
+		}
+	}
+	if target == "" {
+		return fmt.Errorf("version %s: %w", version, ErrKeyNotFound)
+	}
+	if target == current {
+		return nil
+	}
+
+	input := &secretsmanager.UpdateSecretVersionStageInput{
+		SecretId:        aws.String(key),
+		VersionStage:    aws.String(awsCurrentStage),
+		MoveToVersionId: aws.String(target),
+	}
+	if current != "" {
+		input.RemoveFromVersionId = aws.String(current)
+	}
+	if _, err := a.client.UpdateSecretVersionStage(ctx, input); err != nil {
+		return fmt.Errorf("rollback aws secret: %w", err)
+	}
+	return nil
+}
+
+// awsBatchSize is the maximum number of secrets of a BatchGetSecretValue call.
+const awsBatchSize = 20
+
+// BatchGet implements the BatchGetter.BatchGet method, getting up to 20 secrets per call.
+// Binary secrets are returned as their raw bytes.
+// If the batch call itself fails, as when IAM policies only grant GetSecretValue,
+// secrets are read one by one instead.
+func (a *AWSStore) BatchGet(ctx context.Context, keys []string) (map[string]string, error) {
+	values := make(map[string]string, len(keys))
+	errs := make(BatchError)
+	batch := true
+	for chunk := range slices.Chunk(keys, awsBatchSize) {
+		if batch {
+			batch = a.batchGet(ctx, chunk, values, errs)
+		}
+		if !batch {
+			a.getEach(ctx, chunk, values, errs)
+		}
+	}
+	if len(errs) > 0 {
+		return values, errs
+	}
+	return values, nil
+}
+
+// getEach gets the secrets of keys one by one, into values and errs.
+func (a *AWSStore) getEach(ctx context.Context, keys []string, values map[string]string, errs BatchError) {
+	for _, k := range keys {
+		// Replaces the results of a failed batch
+		delete(errs, k)
+		v, err := a.GetBytes(ctx, k)
+		switch {
+		case err == nil:
+			values[k] = string(v)
+		case !errors.Is(err, ErrKeyNotFound):
+			errs[k] = err
+		}
+	}
+}
+
+// batchGet gets the secrets of a chunk of keys with BatchGetSecretValue, into values and errs.
+// It returns false if the call itself failed.
+func (a *AWSStore) batchGet(ctx context.Context, chunk []string, values map[string]string, errs BatchError) bool {
+	input := &secretsmanager.BatchGetSecretValueInput{SecretIdList: chunk}
+	for {
+		out, err := a.client.BatchGetSecretValue(ctx, input)
+		if err != nil {
+			return false
+		}
+		for _, v := range out.SecretValues {
+			// Keys may be secret names or ARNs
+			k := aws.ToString(v.Name)
+			if !slices.Contains(chunk, k) {
+				k = aws.ToString(v.ARN)
+			}
+			if v.SecretString == nil {
+				// Binary secrets are returned as GetBytes does
+				values[k] = string(v.SecretBinary)
+				continue
+			}
+			values[k] = *v.SecretString
+		}
+		for _, e := range out.Errors {
+			if aws.ToString(e.ErrorCode) != "ResourceNotFoundException" {
+				errs[aws.ToString(e.SecretId)] = fmt.Errorf("get aws secret: %s: %s", aws.ToString(e.ErrorCode), aws.ToString(e.Message))
+			}
+		}
+		if out.NextToken == nil {
+			return true
+		}
+		input.NextToken = out.NextToken
+	}
 }
 
 // Set implements the Store.Set method.
 func (a *AWSStore) Set(ctx context.Context, key, value string) error {
+	return a.put(ctx, key, aws.String(value), nil)
+}
+
+// SetBytes implements the ByteStore.SetBytes method.
+// Values that are valid UTF-8 are saved as string secrets, the others as binary secrets.
+func (a *AWSStore) SetBytes(ctx context.Context, key string, value []byte) error {
+	if utf8.Valid(value) {
+		return a.put(ctx, key, aws.String(string(value)), nil)
+	}
+	return a.put(ctx, key, nil, value)
+}
+
+// put saves the string or binary value of the secret, creating it if needed.
+func (a *AWSStore) put(ctx context.Context, key string, str *string, bin []byte) error {
 	// Try to get the secret first to check if it exists
 	_, err := a.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
 		SecretId: aws.String(key),
@@ -109,7 +337,8 @@ func (a *AWSStore) Set(ctx context.Context, key, value string) error {
 		if errors.As(err, &rnfe) {
 			_, err = a.client.CreateSecret(ctx, &secretsmanager.CreateSecretInput{
 				Name:         aws.String(key),
-				SecretString: aws.String(value),
+				SecretString: str,
+				SecretBinary: bin,
 			})
 			if err != nil {
 				return fmt.Errorf("create aws secret: %w", err)
@@ -122,7 +351,8 @@ func (a *AWSStore) Set(ctx context.Context, key, value string) error {
 	// If the secret exists, update it
 	_, err = a.client.PutSecretValue(ctx, &secretsmanager.PutSecretValueInput{
 		SecretId:     aws.String(key),
-		SecretString: aws.String(value),
+		SecretString: str,
+		SecretBinary: bin,
 	})
 	if err != nil {
 		return fmt.Errorf("update aws secret: %w", err)
@@ -147,4 +377,23 @@ func (a *AWSStore) Delete(ctx context.Context, key string) error {
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
+
+// List implements the Lister.List method.
+func (a *AWSStore) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	paginator := secretsmanager.NewListSecretsPaginator(a.client, &secretsmanager.ListSecretsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list aws secrets: %w", err)
+		}
+		for _, s := range page.SecretList {
+			if k := aws.ToString(s.Name); strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
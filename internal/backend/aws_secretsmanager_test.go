@@ -3,7 +3,9 @@ package backend
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
@@ -25,6 +27,14 @@ func (m *MockAWSSecretsManagerClient) GetSecretValue(ctx context.Context, input
 	return args.Get(0).(*secretsmanager.GetSecretValueOutput), args.Error(1)
 }
 
+func (m *MockAWSSecretsManagerClient) BatchGetSecretValue(ctx context.Context, input *secretsmanager.BatchGetSecretValueInput, opts ...func(*secretsmanager.Options)) (*secretsmanager.BatchGetSecretValueOutput, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*secretsmanager.BatchGetSecretValueOutput), args.Error(1)
+}
+
 func (m *MockAWSSecretsManagerClient) CreateSecret(ctx context.Context, input *secretsmanager.CreateSecretInput, opts ...func(*secretsmanager.Options)) (*secretsmanager.CreateSecretOutput, error) {
 	args := m.Called(ctx, input)
 	if args.Get(0) == nil {
@@ -49,6 +59,30 @@ func (m *MockAWSSecretsManagerClient) DeleteSecret(ctx context.Context, input *s
 	return args.Get(0).(*secretsmanager.DeleteSecretOutput), args.Error(1)
 }
 
+func (m *MockAWSSecretsManagerClient) ListSecrets(ctx context.Context, input *secretsmanager.ListSecretsInput, opts ...func(*secretsmanager.Options)) (*secretsmanager.ListSecretsOutput, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*secretsmanager.ListSecretsOutput), args.Error(1)
+}
+
+func (m *MockAWSSecretsManagerClient) ListSecretVersionIds(ctx context.Context, input *secretsmanager.ListSecretVersionIdsInput, opts ...func(*secretsmanager.Options)) (*secretsmanager.ListSecretVersionIdsOutput, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*secretsmanager.ListSecretVersionIdsOutput), args.Error(1)
+}
+
+func (m *MockAWSSecretsManagerClient) UpdateSecretVersionStage(ctx context.Context, input *secretsmanager.UpdateSecretVersionStageInput, opts ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretVersionStageOutput, error) {
+	args := m.Called(ctx, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*secretsmanager.UpdateSecretVersionStageOutput), args.Error(1)
+}
+
 func TestAWSStoreBuilder_Build(t *testing.T) {
 	t.Run("missing region", func(t *testing.T) {
 		builder := AWSStoreBuilder{}
@@ -111,7 +145,7 @@ func TestAWSStore_Get(t *testing.T) {
 
 		value, err := store.Get(ctx, "test-key")
 		assert.Equal(t, "", value)
-		assert.EqualError(t, err, "aws secret is binary, not string")
+		assert.ErrorIs(t, err, ErrBinary)
 		mockClient.AssertExpectations(t)
 	})
 
@@ -130,6 +164,291 @@ func TestAWSStore_Get(t *testing.T) {
 	})
 }
 
+func TestAWSStore_Bytes(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("get binary secret", func(t *testing.T) {
+		mockClient := new(MockAWSSecretsManagerClient)
+		store := AWSStore{client: mockClient, region: "us-east-1"}
+
+		mockClient.On("GetSecretValue", ctx, &secretsmanager.GetSecretValueInput{
+			SecretId: aws.String("test-key"),
+		}).Return(&secretsmanager.GetSecretValueOutput{SecretBinary: []byte{0x00, 0xff}}, nil)
+
+		value, err := store.GetBytes(ctx, "test-key")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0x00, 0xff}, value)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("get string secret", func(t *testing.T) {
+		mockClient := new(MockAWSSecretsManagerClient)
+		store := AWSStore{client: mockClient, region: "us-east-1"}
+
+		mockClient.On("GetSecretValue", ctx, &secretsmanager.GetSecretValueInput{
+			SecretId: aws.String("test-key"),
+		}).Return(&secretsmanager.GetSecretValueOutput{SecretString: aws.String("text")}, nil)
+
+		value, err := store.GetBytes(ctx, "test-key")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("text"), value)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("set binary secret", func(t *testing.T) {
+		mockClient := new(MockAWSSecretsManagerClient)
+		store := AWSStore{client: mockClient, region: "us-east-1"}
+
+		mockClient.On("GetSecretValue", ctx, &secretsmanager.GetSecretValueInput{
+			SecretId: aws.String("test-key"),
+		}).Return(&secretsmanager.GetSecretValueOutput{}, nil)
+		mockClient.On("PutSecretValue", ctx, &secretsmanager.PutSecretValueInput{
+			SecretId:     aws.String("test-key"),
+			SecretBinary: []byte{0x00, 0xff},
+		}).Return(&secretsmanager.PutSecretValueOutput{}, nil)
+
+		assert.NoError(t, store.SetBytes(ctx, "test-key", []byte{0x00, 0xff}))
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("set text secret", func(t *testing.T) {
+		mockClient := new(MockAWSSecretsManagerClient)
+		store := AWSStore{client: mockClient, region: "us-east-1"}
+
+		mockClient.On("GetSecretValue", ctx, &secretsmanager.GetSecretValueInput{
+			SecretId: aws.String("test-key"),
+		}).Return(nil, &types.ResourceNotFoundException{Message: aws.String("Secret not found")})
+		mockClient.On("CreateSecret", ctx, &secretsmanager.CreateSecretInput{
+			Name:         aws.String("test-key"),
+			SecretString: aws.String(`{"type":"service_account"}`),
+		}).Return(&secretsmanager.CreateSecretOutput{}, nil)
+
+		assert.NoError(t, store.SetBytes(ctx, "test-key", []byte(`{"type":"service_account"}`)))
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestAWSStore_GetVersion(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("staging label", func(t *testing.T) {
+		mockClient := new(MockAWSSecretsManagerClient)
+		store := AWSStore{client: mockClient, region: "us-east-1"}
+
+		mockClient.On("GetSecretValue", ctx, &secretsmanager.GetSecretValueInput{
+			SecretId:     aws.String("test-key"),
+			VersionStage: aws.String("AWSPREVIOUS"),
+		}).Return(&secretsmanager.GetSecretValueOutput{SecretString: aws.String("old-value")}, nil)
+
+		value, err := store.GetVersion(ctx, "test-key", "AWSPREVIOUS")
+		assert.NoError(t, err)
+		assert.Equal(t, "old-value", value)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("version id", func(t *testing.T) {
+		mockClient := new(MockAWSSecretsManagerClient)
+		store := AWSStore{client: mockClient, region: "us-east-1"}
+
+		mockClient.On("GetSecretValue", ctx, &secretsmanager.GetSecretValueInput{
+			SecretId:  aws.String("test-key"),
+			VersionId: aws.String("3f7b4c1e-90ab-4def-8edc-ba9876543210"),
+		}).Return(nil, &types.ResourceNotFoundException{Message: aws.String("Version not found")})
+
+		_, err := store.GetVersion(ctx, "test-key", "3f7b4c1e-90ab-4def-8edc-ba9876543210")
+		assert.Equal(t, ErrKeyNotFound, err)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestAWSStore_History(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("paginated", func(t *testing.T) {
+		mockClient := new(MockAWSSecretsManagerClient)
+		store := AWSStore{client: mockClient, region: "us-east-1"}
+
+		day := func(d int) *time.Time {
+			return aws.Time(time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC))
+		}
+		mockClient.On("ListSecretVersionIds", ctx, &secretsmanager.ListSecretVersionIdsInput{
+			SecretId:          aws.String("test-key"),
+			IncludeDeprecated: aws.Bool(true),
+		}).Return(&secretsmanager.ListSecretVersionIdsOutput{
+			Versions: []types.SecretVersionsListEntry{
+				{VersionId: aws.String("v1"), CreatedDate: day(1)},
+				{VersionId: aws.String("v3"), CreatedDate: day(3), VersionStages: []string{"AWSCURRENT"}},
+			},
+			NextToken: aws.String("next"),
+		}, nil)
+		mockClient.On("ListSecretVersionIds", ctx, &secretsmanager.ListSecretVersionIdsInput{
+			SecretId:          aws.String("test-key"),
+			IncludeDeprecated: aws.Bool(true),
+			NextToken:         aws.String("next"),
+		}).Return(&secretsmanager.ListSecretVersionIdsOutput{
+			Versions: []types.SecretVersionsListEntry{
+				{VersionId: aws.String("v2"), CreatedDate: day(2), VersionStages: []string{"AWSPREVIOUS"}},
+			},
+		}, nil)
+
+		history, err := store.History(ctx, "test-key")
+		assert.NoError(t, err)
+		assert.Equal(t, []Version{
+			{ID: "v3", Created: *day(3), Current: true, Labels: []string{"AWSCURRENT"}},
+			{ID: "v2", Created: *day(2), Labels: []string{"AWSPREVIOUS"}},
+			{ID: "v1", Created: *day(1)},
+		}, history)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("key not found", func(t *testing.T) {
+		mockClient := new(MockAWSSecretsManagerClient)
+		store := AWSStore{client: mockClient, region: "us-east-1"}
+
+		mockClient.On("ListSecretVersionIds", ctx, mock.Anything).Return(nil, &types.ResourceNotFoundException{Message: aws.String("Secret not found")})
+
+		_, err := store.History(ctx, "test-key")
+		assert.Equal(t, ErrKeyNotFound, err)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestAWSStore_Rollback(t *testing.T) {
+	ctx := context.Background()
+
+	versions := &secretsmanager.ListSecretVersionIdsOutput{
+		Versions: []types.SecretVersionsListEntry{
+			{VersionId: aws.String("v2"), CreatedDate: aws.Time(time.Unix(2, 0)), VersionStages: []string{"AWSCURRENT"}},
+			{VersionId: aws.String("v1"), CreatedDate: aws.Time(time.Unix(1, 0)), VersionStages: []string{"AWSPREVIOUS"}},
+		},
+	}
+
+	t.Run("staging label", func(t *testing.T) {
+		mockClient := new(MockAWSSecretsManagerClient)
+		store := AWSStore{client: mockClient, region: "us-east-1"}
+
+		mockClient.On("ListSecretVersionIds", ctx, mock.Anything).Return(versions, nil)
+		mockClient.On("UpdateSecretVersionStage", ctx, &secretsmanager.UpdateSecretVersionStageInput{
+			SecretId:            aws.String("test-key"),
+			VersionStage:        aws.String("AWSCURRENT"),
+			MoveToVersionId:     aws.String("v1"),
+			RemoveFromVersionId: aws.String("v2"),
+		}).Return(&secretsmanager.UpdateSecretVersionStageOutput{}, nil)
+
+		assert.NoError(t, store.Rollback(ctx, "test-key", "AWSPREVIOUS"))
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("already current", func(t *testing.T) {
+		mockClient := new(MockAWSSecretsManagerClient)
+		store := AWSStore{client: mockClient, region: "us-east-1"}
+
+		mockClient.On("ListSecretVersionIds", ctx, mock.Anything).Return(versions, nil)
+
+		assert.NoError(t, store.Rollback(ctx, "test-key", "v2"))
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		mockClient := new(MockAWSSecretsManagerClient)
+		store := AWSStore{client: mockClient, region: "us-east-1"}
+
+		mockClient.On("ListSecretVersionIds", ctx, mock.Anything).Return(versions, nil)
+
+		assert.ErrorIs(t, store.Rollback(ctx, "test-key", "v9"), ErrKeyNotFound)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestAWSStore_BatchGet(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("successful batch get", func(t *testing.T) {
+		mockClient := new(MockAWSSecretsManagerClient)
+		store := AWSStore{client: mockClient, region: "us-east-1"}
+
+		keys := make([]string, 25)
+		for i := range keys {
+			keys[i] = fmt.Sprintf("key-%d", i)
+		}
+		keys[1] = "arn:aws:secretsmanager:us-east-1:123:secret:key-1"
+
+		mockClient.On("BatchGetSecretValue", ctx, &secretsmanager.BatchGetSecretValueInput{
+			SecretIdList: keys[:20],
+		}).Return(&secretsmanager.BatchGetSecretValueOutput{
+			SecretValues: []types.SecretValueEntry{
+				{Name: aws.String("key-0"), SecretString: aws.String("value-0")},
+				{Name: aws.String("key-1"), ARN: aws.String(keys[1]), SecretString: aws.String("value-1")},
+				{Name: aws.String("key-2"), SecretBinary: []byte("binary-data")},
+			},
+			Errors: []types.APIErrorType{
+				{SecretId: aws.String("key-3"), ErrorCode: aws.String("ResourceNotFoundException")},
+				{SecretId: aws.String("key-4"), ErrorCode: aws.String("AccessDeniedException"), Message: aws.String("denied")},
+			},
+			NextToken: aws.String("next"),
+		}, nil).Once()
+		mockClient.On("BatchGetSecretValue", ctx, &secretsmanager.BatchGetSecretValueInput{
+			SecretIdList: keys[:20],
+			NextToken:    aws.String("next"),
+		}).Return(&secretsmanager.BatchGetSecretValueOutput{
+			SecretValues: []types.SecretValueEntry{{Name: aws.String("key-5"), SecretString: aws.String("value-5")}},
+		}, nil).Once()
+		mockClient.On("BatchGetSecretValue", ctx, &secretsmanager.BatchGetSecretValueInput{
+			SecretIdList: keys[20:],
+		}).Return(&secretsmanager.BatchGetSecretValueOutput{
+			SecretValues: []types.SecretValueEntry{{Name: aws.String("key-24"), SecretString: aws.String("value-24")}},
+		}, nil).Once()
+
+		values, err := store.BatchGet(ctx, keys)
+		assert.Equal(t, map[string]string{
+			"key-0":  "value-0",
+			keys[1]:  "value-1",
+			"key-2":  "binary-data",
+			"key-5":  "value-5",
+			"key-24": "value-24",
+		}, values)
+		var batchErr BatchError
+		if assert.ErrorAs(t, err, &batchErr) {
+			assert.Len(t, batchErr, 1)
+			assert.EqualError(t, batchErr["key-4"], "get aws secret: AccessDeniedException: denied")
+		}
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("batch denied", func(t *testing.T) {
+		mockClient := new(MockAWSSecretsManagerClient)
+		store := AWSStore{client: mockClient, region: "us-east-1"}
+
+		keys := make([]string, 21)
+		for i := range keys {
+			keys[i] = fmt.Sprintf("key-%d", i)
+		}
+		// Only the first chunk tries a batch
+		mockClient.On("BatchGetSecretValue", ctx, mock.Anything).Return(nil, errors.New("AccessDeniedException")).Once()
+		for _, k := range keys[3:] {
+			mockClient.On("GetSecretValue", ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(k)}).
+				Return(&secretsmanager.GetSecretValueOutput{SecretString: aws.String("value")}, nil).Once()
+		}
+		mockClient.On("GetSecretValue", ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String("key-2")}).
+			Return(&secretsmanager.GetSecretValueOutput{SecretBinary: []byte{0x00, 0xff}}, nil).Once()
+		mockClient.On("GetSecretValue", ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String("key-0")}).
+			Return(nil, &types.ResourceNotFoundException{}).Once()
+		mockClient.On("GetSecretValue", ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String("key-1")}).
+			Return(nil, errors.New("throttled")).Once()
+
+		values, err := store.BatchGet(ctx, keys)
+		assert.Len(t, values, 19)
+		assert.Equal(t, "value", values["key-20"])
+		assert.Equal(t, "\x00\xff", values["key-2"])
+		var batchErr BatchError
+		if assert.ErrorAs(t, err, &batchErr) {
+			assert.Len(t, batchErr, 1)
+			assert.EqualError(t, batchErr["key-1"], "get aws secret: throttled")
+		}
+		mockClient.AssertExpectations(t)
+	})
+}
+
 func TestAWSStore_Set(t *testing.T) {
 	ctx := context.Background()
 
@@ -284,4 +603,47 @@ func TestAWSStore_Delete(t *testing.T) {
 		assert.EqualError(t, err, "delete aws secret: delete error")
 		mockClient.AssertExpectations(t)
 	})
-}
\ No newline at end of file
+}
+
+func TestAWSStore_List(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("paginated with prefix", func(t *testing.T) {
+		mockClient := new(MockAWSSecretsManagerClient)
+		store := AWSStore{client: mockClient, region: "us-east-1"}
+
+		mockClient.On("ListSecrets", ctx, &secretsmanager.ListSecretsInput{}).
+			Return(&secretsmanager.ListSecretsOutput{
+				SecretList: []types.SecretListEntry{
+					{Name: aws.String("github_token")},
+					{Name: aws.String("db_password")},
+				},
+				NextToken: aws.String("next"),
+			}, nil)
+		mockClient.On("ListSecrets", ctx, &secretsmanager.ListSecretsInput{NextToken: aws.String("next")}).
+			Return(&secretsmanager.ListSecretsOutput{
+				SecretList: []types.SecretListEntry{
+					{Name: aws.String("GITHUB_TOKEN")},
+					{Name: aws.String("github_app")},
+				},
+			}, nil)
+
+		keys, err := store.List(ctx, "github")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"github_app", "github_token"}, keys)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("list error", func(t *testing.T) {
+		mockClient := new(MockAWSSecretsManagerClient)
+		store := AWSStore{client: mockClient, region: "us-east-1"}
+
+		mockClient.On("ListSecrets", ctx, &secretsmanager.ListSecretsInput{}).
+			Return(nil, errors.New("list error"))
+
+		keys, err := store.List(ctx, "")
+		assert.Nil(t, keys)
+		assert.EqualError(t, err, "list aws secrets: list error")
+		mockClient.AssertExpectations(t)
+	})
+}
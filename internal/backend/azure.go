@@ -0,0 +1,227 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+)
+
+func init() {
+	registerBuilder("azure", func() Builder { return new(AzureStoreBuilder) })
+}
+
+// azurePurgePollInterval is the delay between checks that a secret deletion completed, before purging it.
+var azurePurgePollInterval = time.Second
+
+// AzureStoreBuilder implements the Builder interface for Azure Key Vault.
+type AzureStoreBuilder struct {
+	VaultURL string `toml:"vault-url"`
+	// Purge permanently deletes soft-deleted secrets on Delete.
+	Purge bool `toml:"purge,omitempty"`
+}
+
+// Build returns a new Azure Key Vault store.
+// It authenticates with the default Azure credential chain (environment, workload identity, managed identity, Azure CLI...).
+func (ab *AzureStoreBuilder) Build(ctx context.Context, name string) (Store, error) {
+	if ab.VaultURL == "" {
+		return nil, fmt.Errorf("missing vault-url")
+	}
+	return NewAzureStore(ab.VaultURL, ab.Purge)
+}
+
+// AzureSecretsClient defines the interface for Azure Key Vault secrets operations.
+type AzureSecretsClient interface {
+	GetSecret(context.Context, string, string, *azsecrets.GetSecretOptions) (azsecrets.GetSecretResponse, error)
+	SetSecret(context.Context, string, azsecrets.SetSecretParameters, *azsecrets.SetSecretOptions) (azsecrets.SetSecretResponse, error)
+	DeleteSecret(context.Context, string, *azsecrets.DeleteSecretOptions) (azsecrets.DeleteSecretResponse, error)
+	GetDeletedSecret(context.Context, string, *azsecrets.GetDeletedSecretOptions) (azsecrets.GetDeletedSecretResponse, error)
+	PurgeDeletedSecret(context.Context, string, *azsecrets.PurgeDeletedSecretOptions) (azsecrets.PurgeDeletedSecretResponse, error)
+	NewListSecretPropertiesPager(*azsecrets.ListSecretPropertiesOptions) *runtime.Pager[azsecrets.ListSecretPropertiesResponse]
+}
+
+// AzureStore uses an Azure Key Vault to store secrets.
+//
+// Key Vault secret names are restricted to alphanumerics and dashes, and are case-insensitive.
+// Other characters of a key are escaped as a dash followed by their hexadecimal byte value,
+// e.g. "github_token" is stored as "github-5ftoken".
+type AzureStore struct {
+	client AzureSecretsClient
+	purge  bool
+}
+
+// NewAzureStore creates a new Azure Key Vault Store.
+func NewAzureStore(vaultURL string, purge bool) (*AzureStore, error) {
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, fmt.Errorf("load azure credentials: %w", err)
+	}
+	client, err := azsecrets.NewClient(vaultURL, cred, nil)
+	if err != nil {
+		return nil, fmt.Errorf("setup client: %w", err)
+	}
+	return &AzureStore{client, purge}, nil
+}
+
+// Get implements the Store.Get method.
+func (a *AzureStore) Get(ctx context.Context, k string) (string, error) {
+	name, err := azureSecretName(k)
+	if err != nil {
+		return "", err
+	}
+	res, err := a.client.GetSecret(ctx, name, "", nil)
+	if err != nil {
+		if isAzureNotFound(err) {
+			return "", ErrKeyNotFound
+		}
+		return "", fmt.Errorf("get azure secret: %w", err)
+	}
+	if res.Value == nil {
+		return "", fmt.Errorf("azure secret has no value")
+	}
+	return *res.Value, nil
+}
+
+// Set implements the Store.Set method.
+func (a *AzureStore) Set(ctx context.Context, k, v string) error {
+	name, err := azureSecretName(k)
+	if err != nil {
+		return err
+	}
+	if _, err := a.client.SetSecret(ctx, name, azsecrets.SetSecretParameters{Value: &v}, nil); err != nil {
+		return fmt.Errorf("set azure secret: %w", err)
+	}
+	return nil
+}
+
+// Delete implements the Store.Delete method.
+// Deleted secrets are kept by Key Vault soft-delete, unless the store is configured to purge them.
+func (a *AzureStore) Delete(ctx context.Context, k string) error {
+	name, err := azureSecretName(k)
+	if err != nil {
+		return err
+	}
+	if _, err := a.client.DeleteSecret(ctx, name, nil); err != nil {
+		if isAzureNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("delete azure secret: %w", err)
+	}
+	if !a.purge {
+		return nil
+	}
+
+	// Deletion is asynchronous, wait for it to complete before purging
+	for {
+		_, err := a.client.GetDeletedSecret(ctx, name, nil)
+		if err == nil {
+			break
+		}
+		if !isAzureNotFound(err) {
+			return fmt.Errorf("get deleted azure secret: %w", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(azurePurgePollInterval):
+		}
+	}
+	if _, err := a.client.PurgeDeletedSecret(ctx, name, nil); err != nil {
+		return fmt.Errorf("purge azure secret: %w", err)
+	}
+	return nil
+}
+
+// List implements the Lister.List method.
+func (a *AzureStore) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	pager := a.client.NewListSecretPropertiesPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list azure secrets: %w", err)
+		}
+		for _, s := range page.Value {
+			if s.ID == nil {
+				continue
+			}
+			k, err := azureKey(s.ID.Name())
+			if err != nil {
+				// Not a secret managed by clef
+				continue
+			}
+			if strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+func isAzureNotFound(err error) bool {
+	var rerr *azcore.ResponseError
+	return errors.As(err, &rerr) && rerr.StatusCode == http.StatusNotFound
+}
+
+// azureMaxNameLength is the maximum length of a Key Vault secret name.
+const azureMaxNameLength = 127
+
+// azureSecretName maps a key to a valid Key Vault secret name.
+// Keys made of alphanumerics and single dashes are used as is. Other characters are escaped as -- and their
+// hexadecimal byte value, and so are dashes followed by another dash, for names to map back to a single key.
+func azureSecretName(k string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(k); i++ {
+		c := k[i]
+		switch {
+		case c == '-' && (i+1 == len(k) || k[i+1] != '-'):
+			b.WriteByte(c)
+		case ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9'):
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "--%02x", c)
+		}
+	}
+	name := b.String()
+	if name == "" {
+		return "", errors.New("empty key")
+	}
+	if len(name) > azureMaxNameLength {
+		return "", fmt.Errorf("key %s is stored as a %d characters azure secret name, the maximum is %d", k, len(name), azureMaxNameLength)
+	}
+	return name, nil
+}
+
+// azureKey maps back a Key Vault secret name to its key.
+// Names that no key maps to are not managed by clef and return an error.
+func azureKey(name string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c == '-' && i+3 < len(name) && name[i+1] == '-' && isLowerHex(name[i+2]) && isLowerHex(name[i+3]) {
+			v, _ := strconv.ParseUint(name[i+2:i+4], 16, 8)
+			c = byte(v)
+			i += 3
+		}
+		b.WriteByte(c)
+	}
+	k := b.String()
+	if n, err := azureSecretName(k); err != nil || n != name {
+		return "", fmt.Errorf("%s is not a clef secret name", name)
+	}
+	return k, nil
+}
+
+func isLowerHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f')
+}
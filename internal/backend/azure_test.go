@@ -0,0 +1,203 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var azureNotFound = &azcore.ResponseError{StatusCode: http.StatusNotFound, ErrorCode: "SecretNotFound"}
+
+func TestAzureStoreBuilder_Build(t *testing.T) {
+	t.Run("missing vault-url", func(t *testing.T) {
+		builder := AzureStoreBuilder{}
+		store, err := builder.Build(context.Background(), "test")
+		assert.Nil(t, store)
+		assert.EqualError(t, err, "missing vault-url")
+	})
+}
+
+func TestAzureSecretName(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]string{
+		"github_token":   "github--5ftoken",
+		"GITHUB-TOKEN":   "GITHUB-TOKEN",
+		"app-db1":        "app-db1",
+		"db/password.v2": "db--2fpassword--2ev2",
+		"a--b":           "a--2d-b",
+		"a-_":            "a---5f",
+		"x--2d":          "x--2d-2d",
+	}
+	for key, name := range tcs {
+		t.Run(key, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := azureSecretName(key)
+			if assert.NoError(t, err) {
+				assert.Equal(t, name, got)
+			}
+			back, err := azureKey(name)
+			if assert.NoError(t, err) {
+				assert.Equal(t, key, back)
+			}
+		})
+	}
+
+	t.Run("too long", func(t *testing.T) {
+		t.Parallel()
+		_, err := azureSecretName(strings.Repeat("a", 127))
+		assert.NoError(t, err)
+		_, err = azureSecretName(strings.Repeat("a", 124) + "_")
+		assert.EqualError(t, err, "key "+strings.Repeat("a", 124)+"_ is stored as a 128 characters azure secret name, the maximum is 127")
+	})
+
+	t.Run("not managed", func(t *testing.T) {
+		t.Parallel()
+		// --41 is the escape of A, which is never escaped
+		_, err := azureKey("a--41")
+		assert.EqualError(t, err, "a--41 is not a clef secret name")
+	})
+}
+
+func TestAzureStore_Get(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nominal", func(t *testing.T) {
+		t.Parallel()
+		client := NewMockAzureSecretsClient(t)
+		client.EXPECT().GetSecret(mock.Anything, "github--5ftoken", "", (*azsecrets.GetSecretOptions)(nil)).
+			Return(azsecrets.GetSecretResponse{Secret: azsecrets.Secret{Value: to.Ptr("bar")}}, nil)
+		store := &AzureStore{client: client}
+
+		v, err := store.Get(context.TODO(), "github_token")
+		assert.NoError(t, err)
+		assert.Equal(t, "bar", v)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+		client := NewMockAzureSecretsClient(t)
+		client.EXPECT().GetSecret(mock.Anything, "foo", "", (*azsecrets.GetSecretOptions)(nil)).
+			Return(azsecrets.GetSecretResponse{}, azureNotFound)
+		store := &AzureStore{client: client}
+
+		_, err := store.Get(context.TODO(), "foo")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+		therr := errors.New("oops")
+		client := NewMockAzureSecretsClient(t)
+		client.EXPECT().GetSecret(mock.Anything, "foo", "", (*azsecrets.GetSecretOptions)(nil)).
+			Return(azsecrets.GetSecretResponse{}, therr)
+		store := &AzureStore{client: client}
+
+		_, err := store.Get(context.TODO(), "foo")
+		assert.ErrorIs(t, err, therr)
+	})
+}
+
+func TestAzureStore_Set(t *testing.T) {
+	t.Parallel()
+
+	client := NewMockAzureSecretsClient(t)
+	client.EXPECT().SetSecret(mock.Anything, "github--5ftoken", azsecrets.SetSecretParameters{Value: to.Ptr("bar")}, (*azsecrets.SetSecretOptions)(nil)).
+		Return(azsecrets.SetSecretResponse{}, nil)
+	store := &AzureStore{client: client}
+
+	assert.NoError(t, store.Set(context.TODO(), "github_token", "bar"))
+}
+
+func TestAzureStore_Delete(t *testing.T) {
+	azurePurgePollInterval = time.Millisecond
+
+	t.Run("soft delete", func(t *testing.T) {
+		client := NewMockAzureSecretsClient(t)
+		client.EXPECT().DeleteSecret(mock.Anything, "foo", (*azsecrets.DeleteSecretOptions)(nil)).
+			Return(azsecrets.DeleteSecretResponse{}, nil)
+		store := &AzureStore{client: client}
+
+		assert.NoError(t, store.Delete(context.TODO(), "foo"))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		client := NewMockAzureSecretsClient(t)
+		client.EXPECT().DeleteSecret(mock.Anything, "foo", (*azsecrets.DeleteSecretOptions)(nil)).
+			Return(azsecrets.DeleteSecretResponse{}, azureNotFound)
+		store := &AzureStore{client: client, purge: true}
+
+		assert.NoError(t, store.Delete(context.TODO(), "foo"))
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		client := NewMockAzureSecretsClient(t)
+		client.EXPECT().DeleteSecret(mock.Anything, "foo", (*azsecrets.DeleteSecretOptions)(nil)).
+			Return(azsecrets.DeleteSecretResponse{}, nil)
+		// Deletion is still in progress on the first check
+		client.EXPECT().GetDeletedSecret(mock.Anything, "foo", (*azsecrets.GetDeletedSecretOptions)(nil)).
+			Return(azsecrets.GetDeletedSecretResponse{}, azureNotFound).Once()
+		client.EXPECT().GetDeletedSecret(mock.Anything, "foo", (*azsecrets.GetDeletedSecretOptions)(nil)).
+			Return(azsecrets.GetDeletedSecretResponse{}, nil).Once()
+		client.EXPECT().PurgeDeletedSecret(mock.Anything, "foo", (*azsecrets.PurgeDeletedSecretOptions)(nil)).
+			Return(azsecrets.PurgeDeletedSecretResponse{}, nil)
+		store := &AzureStore{client: client, purge: true}
+
+		assert.NoError(t, store.Delete(context.TODO(), "foo"))
+	})
+}
+
+func TestAzureStore_List(t *testing.T) {
+	t.Parallel()
+
+	pages := []azsecrets.ListSecretPropertiesResponse{
+		{SecretPropertiesListResult: azsecrets.SecretPropertiesListResult{
+			NextLink: to.Ptr("next"),
+			Value: []*azsecrets.SecretProperties{
+				{ID: to.Ptr(azsecrets.ID("https://v.vault.azure.net/secrets/github--5ftoken"))},
+				{ID: to.Ptr(azsecrets.ID("https://v.vault.azure.net/secrets/github-app-db1"))},
+				{ID: to.Ptr(azsecrets.ID("https://v.vault.azure.net/secrets/github--41"))},
+			},
+		}},
+		{SecretPropertiesListResult: azsecrets.SecretPropertiesListResult{
+			Value: []*azsecrets.SecretProperties{
+				{ID: to.Ptr(azsecrets.ID("https://v.vault.azure.net/secrets/github--5fapp"))},
+				{ID: to.Ptr(azsecrets.ID("https://v.vault.azure.net/secrets/GitHub-Token"))},
+				{ID: to.Ptr(azsecrets.ID("https://v.vault.azure.net/secrets/db-password"))},
+			},
+		}},
+	}
+	client := NewMockAzureSecretsClient(t)
+	client.EXPECT().NewListSecretPropertiesPager((*azsecrets.ListSecretPropertiesOptions)(nil)).
+		RunAndReturn(func(*azsecrets.ListSecretPropertiesOptions) *runtime.Pager[azsecrets.ListSecretPropertiesResponse] {
+			return runtime.NewPager(runtime.PagingHandler[azsecrets.ListSecretPropertiesResponse]{
+				More: func(page azsecrets.ListSecretPropertiesResponse) bool { return page.NextLink != nil },
+				Fetcher: func(_ context.Context, page *azsecrets.ListSecretPropertiesResponse) (azsecrets.ListSecretPropertiesResponse, error) {
+					if page == nil {
+						return pages[0], nil
+					}
+					return pages[1], nil
+				},
+			})
+		})
+	store := &AzureStore{client: client}
+
+	keys, err := store.List(context.TODO(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"GitHub-Token", "db-password", "github-app-db1", "github_app", "github_token"}, keys)
+
+	keys, err = store.List(context.TODO(), "github")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"github-app-db1", "github_app", "github_token"}, keys)
+}
@@ -3,6 +3,12 @@ package backend
 import (
 	"context"
 	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 var (
@@ -10,9 +16,18 @@ var (
 	ErrKeyNotFound = errors.New("key not found")
 	// ErrReservedStoreName means the store name cannot be used
 	ErrReservedStoreName = errors.New("reserved store name")
+	// ErrReadOnly means the store is configured to refuse writes
+	ErrReadOnly = errors.New("read-only store")
+	// ErrUnversioned means the store doesn't keep versions of its secrets
+	ErrUnversioned = errors.New("store does not support versions")
+	// ErrBinary means the value is binary, and the store only holds text
+	ErrBinary = errors.New("store does not support binary values")
 )
 
 // Store represents a store abstraction.
+//
+// Stores holding resources, like a client connection or a child process, also implement [io.Closer].
+// Whoever builds a store is responsible for closing it.
 type Store interface {
 	// Get returns the value at key from the store, or an error.
 	Get(ctx context.Context, key string) (string, error)
@@ -22,6 +37,106 @@ type Store interface {
 	Delete(ctx context.Context, key string) error
 }
 
+// ByteStore is implemented by stores holding arbitrary bytes, not only text.
+type ByteStore interface {
+	// GetBytes returns the value at key from the store, or an error.
+	GetBytes(ctx context.Context, key string) ([]byte, error)
+	// SetBytes store the value in the store at key.
+	SetBytes(ctx context.Context, key string, value []byte) error
+}
+
+// GetBytes returns the value at key from s, as bytes.
+func GetBytes(ctx context.Context, s Store, key string) ([]byte, error) {
+	if bs, ok := s.(ByteStore); ok {
+		return bs.GetBytes(ctx, key)
+	}
+	v, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(v), nil
+}
+
+// SetBytes stores value at key in s. Stores holding text only refuse values that are not valid UTF-8.
+func SetBytes(ctx context.Context, s Store, key string, value []byte) error {
+	if bs, ok := s.(ByteStore); ok {
+		return bs.SetBytes(ctx, key, value)
+	}
+	if !utf8.Valid(value) {
+		return ErrBinary
+	}
+	return s.Set(ctx, key, string(value))
+}
+
+// Lister is implemented by stores able to enumerate the keys they hold.
+type Lister interface {
+	// List returns the sorted keys of the store starting with prefix.
+	// An empty prefix lists every key.
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// BatchGetter is implemented by stores able to get several keys in a single round trip.
+type BatchGetter interface {
+	// BatchGet returns the values of keys, by key. Keys that are not found are missing from the result.
+	// The error may be a BatchError, reporting the keys that failed; any other error fails the whole batch.
+	BatchGet(ctx context.Context, keys []string) (map[string]string, error)
+}
+
+// VersionGetter is implemented by stores keeping the previous versions of their secrets.
+type VersionGetter interface {
+	// GetVersion returns the value of key at version, a version id or an alias understood by the store.
+	GetVersion(ctx context.Context, key, version string) (string, error)
+}
+
+// Version describes a version of a secret.
+type Version struct {
+	// ID identifies the version, for GetVersion and Rollback.
+	ID string
+	// Created is when the version was created.
+	Created time.Time
+	// Current marks the version returned by Get.
+	Current bool
+	// Labels are store specific attributes, like the version state or staging labels.
+	Labels []string
+}
+
+// Versioned is implemented by stores keeping the history of their secrets.
+type Versioned interface {
+	VersionGetter
+	// History returns the versions of key, newest first.
+	History(ctx context.Context, key string) ([]Version, error)
+	// Rollback makes version the current value of key.
+	Rollback(ctx context.Context, key, version string) error
+}
+
+// GetVersion returns the value of key at version from s, or its current value if version is empty.
+func GetVersion(ctx context.Context, s Store, key, version string) (string, error) {
+	if version == "" {
+		return s.Get(ctx, key)
+	}
+	vg, ok := s.(VersionGetter)
+	if !ok {
+		return "", ErrUnversioned
+	}
+	return vg.GetVersion(ctx, key, version)
+}
+
+// BatchError reports the errors of a BatchGet by key.
+type BatchError map[string]error
+
+func (e BatchError) Error() string {
+	keys := slices.Sorted(maps.Keys(e))
+	msgs := make([]string, len(keys))
+	for i, k := range keys {
+		msgs[i] = fmt.Sprintf("get %s: %s", k, e[k])
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e BatchError) Unwrap() []error {
+	return slices.Collect(maps.Values(e))
+}
+
 type StoreLoader interface {
 	Backend(context.Context, string) (Store, error)
 }
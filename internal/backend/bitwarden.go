@@ -0,0 +1,321 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+func init() {
+	registerBuilder("bitwarden", func() Builder { return new(BitwardenStoreBuilder) })
+}
+
+// BitwardenStoreBuilder implements the Builder interface for Bitwarden.
+//
+// It drives the bw CLI, which must be logged in. Point it at a self-hosted Vaultwarden with `bw config server`.
+// The vault is unlocked with the BW_SESSION environment variable, a session cached in the system store,
+// or the master password, read from BW_PASSWORD or prompted.
+type BitwardenStoreBuilder struct {
+	// CacheSession caches the session key in the system store once unlocked.
+	CacheSession bool `toml:"cache-session,omitempty"`
+	// Sync pulls the latest vault data from the server when the store is loaded.
+	Sync bool `toml:"sync,omitempty"`
+}
+
+// Build returns a new BitwardenStore store.
+func (bb *BitwardenStoreBuilder) Build(ctx context.Context, name string) (Store, error) {
+	store := &BitwardenStore{bin: "bw"}
+	if err := bb.unlock(ctx, store, name); err != nil {
+		return nil, fmt.Errorf("unlock bitwarden: %w", err)
+	}
+	if bb.Sync {
+		if _, err := store.run(ctx, nil, "sync"); err != nil {
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+func (bb *BitwardenStoreBuilder) unlock(ctx context.Context, bs *BitwardenStore, name string) error {
+	if bs.session = os.Getenv("BW_SESSION"); bs.session != "" {
+		return nil
+	}
+
+	cacheKey := "bitwarden:" + name
+	if bb.CacheSession {
+		if session, err := SystemStore.Get(ctx, cacheKey); err == nil {
+			bs.session = session
+			if _, err := bs.run(ctx, nil, "unlock", "--check"); err == nil {
+				return nil
+			}
+			bs.session = ""
+		}
+	}
+
+	password := os.Getenv("BW_PASSWORD")
+	if password == "" {
+		var err error
+		password, err = promptSecret("Bitwarden master password: ")
+		if err != nil {
+			return fmt.Errorf("read master password: %w (set BW_SESSION or BW_PASSWORD)", err)
+		}
+	}
+	// The password is handed over through the environment, to keep it out of the process list
+	session, err := bs.command(ctx, []string{"BW_PASSWORD=" + password}, nil, "unlock", "--raw", "--passwordenv", "BW_PASSWORD")
+	if err != nil {
+		return err
+	}
+	bs.session = strings.TrimSpace(string(session))
+
+	if bb.CacheSession {
+		if err := SystemStore.Set(ctx, cacheKey, bs.session); err != nil {
+			return fmt.Errorf("cache session: %w", err)
+		}
+	}
+	return nil
+}
+
+// BitwardenStore uses a Bitwarden vault to store secrets, through the bw CLI.
+//
+// Keys are item names, optionally followed by a field name, as item[/field].
+// The field defaults to the login password. The username and notes fields are supported,
+// any other name refers to a custom field.
+// Set creates missing items as logins, and missing custom fields as hidden fields.
+// Delete clears the field, but never deletes the item itself.
+type BitwardenStore struct {
+	bin     string
+	session string
+}
+
+// bwItem is a Bitwarden item, kept as raw JSON to preserve every attribute on edit.
+type bwItem map[string]any
+
+func (bs *BitwardenStore) run(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	return bs.command(ctx, nil, stdin, args...)
+}
+
+// command runs bw with args, adding env to the environment.
+func (bs *BitwardenStore) command(ctx context.Context, env []string, stdin []byte, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bs.bin, append(args, "--nointeraction")...)
+	cmd.Env = append(os.Environ(), env...)
+	if bs.session != "" {
+		cmd.Env = append(cmd.Env, "BW_SESSION="+bs.session)
+	}
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("bw %s: %s", args[0], msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+func splitBWKey(k string) (string, string) {
+	if i := strings.LastIndex(k, "/"); i >= 0 {
+		return k[:i], k[i+1:]
+	}
+	return k, "password"
+}
+
+func (bs *BitwardenStore) items(ctx context.Context, args ...string) ([]bwItem, error) {
+	out, err := bs.run(ctx, nil, append([]string{"list", "items"}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	var items []bwItem
+	if err := json.Unmarshal(out, &items); err != nil {
+		return nil, fmt.Errorf("decode bitwarden items: %w", err)
+	}
+	return items, nil
+}
+
+// item returns the item named name. The bw search being fuzzy, results are matched exactly.
+func (bs *BitwardenStore) item(ctx context.Context, name string) (bwItem, error) {
+	items, err := bs.items(ctx, "--search", name)
+	if err != nil {
+		return nil, err
+	}
+	var found []bwItem
+	for _, it := range items {
+		if it["name"] == name {
+			found = append(found, it)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, ErrKeyNotFound
+	case 1:
+		return found[0], nil
+	default:
+		return nil, fmt.Errorf("%d items named %s", len(found), name)
+	}
+}
+
+func (bs *BitwardenStore) write(ctx context.Context, it bwItem, args ...string) error {
+	b, err := json.Marshal(it)
+	if err != nil {
+		return err
+	}
+	// bw reads the base64 encoded item from stdin, keeping values out of the process list
+	_, err = bs.run(ctx, []byte(base64.StdEncoding.EncodeToString(b)), args...)
+	return err
+}
+
+func (it bwItem) login() map[string]any {
+	login, _ := it["login"].(map[string]any)
+	return login
+}
+
+func (it bwItem) fields() []any {
+	fields, _ := it["fields"].([]any)
+	return fields
+}
+
+func (it bwItem) customField(name string) map[string]any {
+	for _, f := range it.fields() {
+		if f, ok := f.(map[string]any); ok && f["name"] == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// get returns the value of field, or false if it is not set.
+func (it bwItem) get(field string) (string, bool) {
+	var v any
+	switch field {
+	case "password", "username":
+		v = it.login()[field]
+	case "notes":
+		v = it["notes"]
+	default:
+		v = it.customField(field)["value"]
+	}
+	s, ok := v.(string)
+	return s, ok
+}
+
+func (it bwItem) set(field string, v any) error {
+	switch field {
+	case "password", "username":
+		login := it.login()
+		if login == nil {
+			return fmt.Errorf("item %s is not a login", it["name"])
+		}
+		login[field] = v
+	case "notes":
+		it["notes"] = v
+	default:
+		if f := it.customField(field); f != nil {
+			f["value"] = v
+			return nil
+		}
+		// Hidden field
+		it["fields"] = append(it.fields(), map[string]any{"name": field, "value": v, "type": 1})
+	}
+	return nil
+}
+
+func (it bwItem) unset(field string) {
+	switch field {
+	case "password", "username", "notes":
+		_ = it.set(field, nil)
+	default:
+		it["fields"] = slices.DeleteFunc(it.fields(), func(f any) bool {
+			f2, ok := f.(map[string]any)
+			return ok && f2["name"] == field
+		})
+	}
+}
+
+// Get implements the Store.Get method.
+func (bs *BitwardenStore) Get(ctx context.Context, k string) (string, error) {
+	name, field := splitBWKey(k)
+	it, err := bs.item(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	v, ok := it.get(field)
+	if !ok {
+		return "", fmt.Errorf("field %s: %w", field, ErrKeyNotFound)
+	}
+	return v, nil
+}
+
+// Set implements the Store.Set method.
+func (bs *BitwardenStore) Set(ctx context.Context, k, v string) error {
+	name, field := splitBWKey(k)
+	it, err := bs.item(ctx, name)
+	if errors.Is(err, ErrKeyNotFound) {
+		it = bwItem{"type": 1, "name": name, "login": map[string]any{}}
+		if err := it.set(field, v); err != nil {
+			return err
+		}
+		return bs.write(ctx, it, "create", "item")
+	}
+	if err != nil {
+		return err
+	}
+	if err := it.set(field, v); err != nil {
+		return err
+	}
+	return bs.write(ctx, it, "edit", "item", fmt.Sprint(it["id"]))
+}
+
+// Delete implements the Store.Delete method.
+func (bs *BitwardenStore) Delete(ctx context.Context, k string) error {
+	name, field := splitBWKey(k)
+	it, err := bs.item(ctx, name)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, ok := it.get(field); !ok {
+		return nil
+	}
+	it.unset(field)
+	return bs.write(ctx, it, "edit", "item", fmt.Sprint(it["id"]))
+}
+
+// List implements the Lister.List method.
+// It lists the items holding a password by name, and their custom fields as item/field.
+func (bs *BitwardenStore) List(ctx context.Context, prefix string) ([]string, error) {
+	items, err := bs.items(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, it := range items {
+		name, _ := it["name"].(string)
+		candidates := []string{}
+		if _, ok := it.get("password"); ok {
+			candidates = append(candidates, name)
+		}
+		for _, f := range it.fields() {
+			if f, ok := f.(map[string]any); ok {
+				candidates = append(candidates, fmt.Sprintf("%s/%v", name, f["name"]))
+			}
+		}
+		for _, k := range candidates {
+			if strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
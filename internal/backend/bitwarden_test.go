@@ -0,0 +1,189 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+const testBWItems = `[
+  {"id":"1","type":1,"name":"github","login":{"username":"octocat","password":"hunter2"},"fields":[{"name":"token","value":"ghp_123","type":1}],"folderId":"f1"},
+  {"id":"2","type":1,"name":"github-enterprise","login":{"username":"octocat","password":"other"}},
+  {"id":"3","type":2,"name":"api","notes":"some notes","secureNote":{"type":0}}
+]`
+
+// fakeBW writes a bw executable serving the items of testBWItems, when unlocked with the "session" key.
+// Written items are saved, decoded, to the returned file.
+func fakeBW(t *testing.T) (*BitwardenStore, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake bw CLI requires a POSIX shell")
+	}
+	dir := t.TempDir()
+	items := filepath.Join(dir, "items.json")
+	written := filepath.Join(dir, "written")
+	require.NoError(t, os.WriteFile(items, []byte(testBWItems), 0600))
+	script := fmt.Sprintf(`#!/bin/sh
+case "$1 $2" in
+"unlock --raw")
+  [ "$BW_PASSWORD" = master ] || { echo "Invalid master password." >&2; exit 1; }
+  echo session; exit 0;;
+"unlock --check")
+  [ "$BW_SESSION" = session ] || { echo "Vault is locked." >&2; exit 1; }
+  exit 0;;
+esac
+[ "$BW_SESSION" = session ] || { echo "Vault is locked." >&2; exit 1; }
+case "$1" in
+list) cat %s;;
+create|edit) echo "$@" > %s; base64 -d >> %s;;
+esac
+`, items, written, written)
+	bin := filepath.Join(dir, "bw")
+	require.NoError(t, os.WriteFile(bin, []byte(script), 0o755))
+	return &BitwardenStore{bin: bin, session: "session"}, written
+}
+
+// readWritten returns the arguments and item of the last bw create or edit call.
+func readWritten(t *testing.T, written string) (string, bwItem) {
+	t.Helper()
+	b, err := os.ReadFile(written)
+	require.NoError(t, err)
+	args, data, _ := bytes.Cut(b, []byte("\n"))
+	var it bwItem
+	require.NoError(t, json.Unmarshal(data, &it))
+	return string(args), it
+}
+
+func TestBitwardenStoreBuilder_unlock(t *testing.T) {
+	t.Run("session from env", func(t *testing.T) {
+		t.Setenv("BW_SESSION", "from-env")
+		store := &BitwardenStore{bin: "bw"}
+		require.NoError(t, (&BitwardenStoreBuilder{}).unlock(context.TODO(), store, "test"))
+		assert.Equal(t, "from-env", store.session)
+	})
+
+	t.Run("master password", func(t *testing.T) {
+		keyring.MockInit()
+		t.Setenv("BW_SESSION", "")
+		t.Setenv("BW_PASSWORD", "master")
+		store, _ := fakeBW(t)
+		store.session = ""
+
+		builder := &BitwardenStoreBuilder{CacheSession: true}
+		require.NoError(t, builder.unlock(context.TODO(), store, "test"))
+		assert.Equal(t, "session", store.session)
+		cached, err := SystemStore.Get(context.TODO(), "bitwarden:test")
+		require.NoError(t, err)
+		assert.Equal(t, "session", cached)
+
+		// Cached session is reused
+		t.Setenv("BW_PASSWORD", "wrong")
+		store.session = ""
+		require.NoError(t, builder.unlock(context.TODO(), store, "test"))
+		assert.Equal(t, "session", store.session)
+
+		// Stale session needs the master password
+		require.NoError(t, SystemStore.Set(context.TODO(), "bitwarden:test", "stale"))
+		store.session = ""
+		err = builder.unlock(context.TODO(), store, "test")
+		assert.EqualError(t, err, "bw unlock: Invalid master password.")
+	})
+}
+
+func TestBitwardenStore_Get(t *testing.T) {
+	store, _ := fakeBW(t)
+	ctx := context.TODO()
+
+	tcs := map[string]string{
+		"github":          "hunter2",
+		"github/password": "hunter2",
+		"github/username": "octocat",
+		"github/token":    "ghp_123",
+		"api/notes":       "some notes",
+	}
+	for k, expected := range tcs {
+		v, err := store.Get(ctx, k)
+		if assert.NoError(t, err, k) {
+			assert.Equal(t, expected, v, k)
+		}
+	}
+
+	for _, k := range []string{"gitlab", "github/nofield", "api"} {
+		_, err := store.Get(ctx, k)
+		assert.ErrorIs(t, err, ErrKeyNotFound, k)
+	}
+
+	locked := &BitwardenStore{bin: store.bin}
+	_, err := locked.Get(ctx, "github")
+	assert.EqualError(t, err, "bw list: Vault is locked.")
+}
+
+func TestBitwardenStore_Set(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("edit password", func(t *testing.T) {
+		store, written := fakeBW(t)
+		require.NoError(t, store.Set(ctx, "github", "new"))
+		args, it := readWritten(t, written)
+		assert.Equal(t, "edit item 1 --nointeraction", args)
+		assert.Equal(t, "new", it.login()["password"])
+		assert.Equal(t, "f1", it["folderId"], "other attributes are preserved")
+	})
+
+	t.Run("add custom field", func(t *testing.T) {
+		store, written := fakeBW(t)
+		require.NoError(t, store.Set(ctx, "github/pat", "github_pat_1"))
+		_, it := readWritten(t, written)
+		assert.Len(t, it.fields(), 2)
+		assert.Equal(t, map[string]any{"name": "pat", "value": "github_pat_1", "type": float64(1)}, it.customField("pat"))
+	})
+
+	t.Run("create item", func(t *testing.T) {
+		store, written := fakeBW(t)
+		require.NoError(t, store.Set(ctx, "npm", "npm_1"))
+		args, it := readWritten(t, written)
+		assert.Equal(t, "create item --nointeraction", args)
+		assert.Equal(t, bwItem{"type": float64(1), "name": "npm", "login": map[string]any{"password": "npm_1"}}, it)
+	})
+
+	t.Run("not a login", func(t *testing.T) {
+		store, _ := fakeBW(t)
+		assert.EqualError(t, store.Set(ctx, "api", "x"), "item api is not a login")
+	})
+}
+
+func TestBitwardenStore_Delete(t *testing.T) {
+	ctx := context.TODO()
+
+	store, written := fakeBW(t)
+	require.NoError(t, store.Delete(ctx, "github/token"))
+	_, it := readWritten(t, written)
+	assert.Empty(t, it.fields())
+	assert.Equal(t, "hunter2", it.login()["password"])
+
+	require.NoError(t, os.Remove(written))
+	require.NoError(t, store.Delete(ctx, "github/nofield"))
+	require.NoError(t, store.Delete(ctx, "gitlab"))
+	assert.NoFileExists(t, written)
+}
+
+func TestBitwardenStore_List(t *testing.T) {
+	store, _ := fakeBW(t)
+
+	keys, err := store.List(context.TODO(), "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"github", "github-enterprise", "github/token"}, keys)
+
+	keys, err = store.List(context.TODO(), "github/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"github/token"}, keys)
+}
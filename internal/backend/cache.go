@@ -0,0 +1,380 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"filippo.io/age"
+	"github.com/adrg/xdg"
+	"github.com/zalando/go-keyring"
+)
+
+// cacheIdentityKey is the system store key of the identity sealing the file cache.
+const cacheIdentityKey = "cache:identity"
+
+// OpenCache returns the store holding cached secrets: the system store ("system", the default),
+// or an age encrypted file in the user cache directory ("file"), sealed with an identity kept in the system store.
+func OpenCache(ctx context.Context, kind string) (Store, error) {
+	switch kind {
+	case "", "system":
+		return SystemStore, nil
+	case "file":
+		filename, err := xdg.CacheFile("clef/cache.age")
+		if err != nil {
+			return nil, fmt.Errorf("locate cache file: %w", err)
+		}
+		identity, err := cacheIdentity(ctx, filename)
+		if err != nil {
+			return nil, err
+		}
+		return NewEncryptedFileStore(filename, identity, identity.Recipient())
+	default:
+		return nil, fmt.Errorf("unsupported cache backend '%s'", kind)
+	}
+}
+
+// cacheIdentity returns the identity sealing the cache file, generating it on first use.
+func cacheIdentity(ctx context.Context, filename string) (*age.X25519Identity, error) {
+	if s, err := SystemStore.Get(ctx, cacheIdentityKey); err == nil {
+		return age.ParseX25519Identity(s)
+	}
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return nil, err
+	}
+	if err := SystemStore.Set(ctx, cacheIdentityKey, identity.String()); err != nil {
+		return nil, fmt.Errorf("save cache identity: %w", err)
+	}
+	// A cache sealed with a lost identity cannot be read anymore
+	if err := os.Remove(filename); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return identity, nil
+}
+
+func isCacheMiss(err error) bool {
+	return errors.Is(err, ErrKeyNotFound) || errors.Is(err, keyring.ErrNotFound)
+}
+
+// cacheKey is the cache key of the index of the store name: the keys it has cached, and when they expire.
+// Store names are escaped so that they never contain a slash.
+func cacheKey(name string) string {
+	return "cache:" + url.PathEscape(name)
+}
+
+// cacheEntryKey is the cache key of the value of k in the store name.
+func cacheEntryKey(name, k string) string {
+	return cacheKey(name) + "/" + k
+}
+
+// readCacheIndex returns the index of the store name. An unreadable index is empty.
+func readCacheIndex(ctx context.Context, cache Store, name string) map[string]time.Time {
+	index := make(map[string]time.Time)
+	if s, err := cache.Get(ctx, cacheKey(name)); err == nil {
+		_ = json.Unmarshal([]byte(s), &index)
+	}
+	return index
+}
+
+// ClearCache drops the secrets of the store name from cache.
+func ClearCache(ctx context.Context, cache Store, name string) error {
+	for k := range readCacheIndex(ctx, cache, name) {
+		if err := cache.Delete(ctx, cacheEntryKey(name, k)); err != nil && !isCacheMiss(err) {
+			return err
+		}
+	}
+	if err := cache.Delete(ctx, cacheKey(name)); err != nil && !isCacheMiss(err) {
+		return err
+	}
+	return nil
+}
+
+// Uncached returns the store wrapped by s if it is a CachedStore, or s itself.
+func Uncached(s Store) Store {
+	if cs, ok := s.(*CachedStore); ok {
+		return cs.store
+	}
+	return s
+}
+
+// CachedStore is a read-through cache in front of any Store.
+//
+// Values read from the store are kept in a cache store for a TTL, so that loading a profile
+// doesn't hit remote stores every time. Each value is saved as its own cache key, as keyrings
+// limit the size of their entries, and an index of the cached keys lets ClearCache find them.
+// Set and Delete write to the store, and then drop the key from cache.
+// Cache failures are not fatal to reads, the value is then fetched from the store,
+// and the first failure to save a value is reported on stderr.
+type CachedStore struct {
+	store Store
+	cache Store
+	name  string
+	ttl   time.Duration
+
+	// mu serializes the index updates of the process
+	mu sync.Mutex
+	// warn reports the first failure to save a value
+	warn   sync.Once
+	stderr io.Writer
+}
+
+// cacheEntry is a cached value. Values are bytes, base64 in JSON, so that binary values survive the round trip.
+type cacheEntry struct {
+	Value   []byte    `json:"value"`
+	Expires time.Time `json:"expires"`
+}
+
+// NewCachedStore creates a new CachedStore caching the values of store, named name, in cache for ttl.
+func NewCachedStore(store, cache Store, name string, ttl time.Duration) *CachedStore {
+	return &CachedStore{store: store, cache: cache, name: name, ttl: ttl, stderr: os.Stderr}
+}
+
+// updateIndex applies update to the index, drops the expired entries, and saves it.
+// Callers must hold mu.
+func (cs *CachedStore) updateIndex(ctx context.Context, update func(map[string]time.Time)) error {
+	index := readCacheIndex(ctx, cs.cache, cs.name)
+	before := len(index)
+	update(index)
+	now := time.Now()
+	for k, expires := range index {
+		if now.After(expires) {
+			if err := cs.cache.Delete(ctx, cacheEntryKey(cs.name, k)); err != nil && !isCacheMiss(err) {
+				return err
+			}
+			delete(index, k)
+		}
+	}
+	if len(index) == 0 {
+		if before == 0 {
+			return nil
+		}
+		return ClearCache(ctx, cs.cache, cs.name)
+	}
+	b, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return cs.cache.Set(ctx, cacheKey(cs.name), string(b))
+}
+
+// invalidate drops k from cache.
+func (cs *CachedStore) invalidate(ctx context.Context, k string) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if err := cs.cache.Delete(ctx, cacheEntryKey(cs.name, k)); err != nil && !isCacheMiss(err) {
+		return fmt.Errorf("invalidate cache: %w", err)
+	}
+	if _, ok := readCacheIndex(ctx, cs.cache, cs.name)[k]; !ok {
+		return nil
+	}
+	if err := cs.updateIndex(ctx, func(index map[string]time.Time) { delete(index, k) }); err != nil {
+		return fmt.Errorf("invalidate cache: %w", err)
+	}
+	return nil
+}
+
+// lookup returns the cached value of k, if it hasn't expired.
+func (cs *CachedStore) lookup(ctx context.Context, k string) ([]byte, bool) {
+	s, err := cs.cache.Get(ctx, cacheEntryKey(cs.name, k))
+	if err != nil {
+		return nil, false
+	}
+	var e cacheEntry
+	if err := json.Unmarshal([]byte(s), &e); err != nil {
+		return nil, false
+	}
+	return e.Value, time.Now().Before(e.Expires)
+}
+
+// remember caches values. The values are good even if they can't be cached,
+// the first failure is only reported.
+func (cs *CachedStore) remember(ctx context.Context, values map[string][]byte) {
+	if err := cs.save(ctx, values); err != nil {
+		cs.warn.Do(func() {
+			fmt.Fprintf(cs.stderr, "warning: could not cache values of %s store: %v\n", cs.name, err)
+		})
+	}
+}
+
+// save writes values to cache, and adds them to the index.
+func (cs *CachedStore) save(ctx context.Context, values map[string][]byte) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	expires := time.Now().Add(cs.ttl)
+	saved := make([]string, 0, len(values))
+	var errs []error
+	for k, v := range values {
+		b, err := json.Marshal(cacheEntry{Value: v, Expires: expires})
+		if err == nil {
+			err = cs.cache.Set(ctx, cacheEntryKey(cs.name, k), string(b))
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", k, err))
+			continue
+		}
+		saved = append(saved, k)
+	}
+	if len(saved) > 0 {
+		err := cs.updateIndex(ctx, func(index map[string]time.Time) {
+			for _, k := range saved {
+				index[k] = expires
+			}
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("index: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Get implements the Store.Get method.
+func (cs *CachedStore) Get(ctx context.Context, k string) (string, error) {
+	if v, ok := cs.lookup(ctx, k); ok {
+		return string(v), nil
+	}
+
+	v, err := cs.store.Get(ctx, k)
+	if err != nil {
+		return "", err
+	}
+	cs.remember(ctx, map[string][]byte{k: []byte(v)})
+	return v, nil
+}
+
+// GetBytes implements the ByteStore.GetBytes method.
+func (cs *CachedStore) GetBytes(ctx context.Context, k string) ([]byte, error) {
+	if v, ok := cs.lookup(ctx, k); ok {
+		return v, nil
+	}
+
+	v, err := GetBytes(ctx, cs.store, k)
+	if err != nil {
+		return nil, err
+	}
+	cs.remember(ctx, map[string][]byte{k: v})
+	return v, nil
+}
+
+// GetVersion implements the VersionGetter.GetVersion method.
+// Versions are read from the wrapped store, without caching: aliases like latest move.
+func (cs *CachedStore) GetVersion(ctx context.Context, k, version string) (string, error) {
+	return GetVersion(ctx, cs.store, k, version)
+}
+
+// History implements the Versioned.History method, reading the wrapped store.
+func (cs *CachedStore) History(ctx context.Context, k string) ([]Version, error) {
+	v, ok := cs.store.(Versioned)
+	if !ok {
+		return nil, ErrUnversioned
+	}
+	return v.History(ctx, k)
+}
+
+// Rollback implements the Versioned.Rollback method.
+func (cs *CachedStore) Rollback(ctx context.Context, k, version string) error {
+	v, ok := cs.store.(Versioned)
+	if !ok {
+		return ErrUnversioned
+	}
+	if err := v.Rollback(ctx, k, version); err != nil {
+		return err
+	}
+	return cs.invalidate(ctx, k)
+}
+
+// BatchGet implements the BatchGetter.BatchGet method.
+// Keys missing from cache are fetched in a single batch if the wrapped store supports it, or one by one.
+func (cs *CachedStore) BatchGet(ctx context.Context, keys []string) (map[string]string, error) {
+	values := make(map[string]string, len(keys))
+	var missing []string
+	for _, k := range keys {
+		if v, ok := cs.lookup(ctx, k); ok {
+			values[k] = string(v)
+		} else {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	var fetched map[string]string
+	var err error
+	if bg, ok := cs.store.(BatchGetter); ok {
+		fetched, err = bg.BatchGet(ctx, missing)
+	} else {
+		fetched = make(map[string]string, len(missing))
+		errs := make(BatchError)
+		for _, k := range missing {
+			v, err := cs.store.Get(ctx, k)
+			switch {
+			case err == nil:
+				fetched[k] = v
+			case !errors.Is(err, ErrKeyNotFound):
+				errs[k] = err
+			}
+		}
+		if len(errs) > 0 {
+			err = errs
+		}
+	}
+	if fetched == nil {
+		return nil, err
+	}
+
+	cached := make(map[string][]byte, len(fetched))
+	for k, v := range fetched {
+		values[k] = v
+		cached[k] = []byte(v)
+	}
+	cs.remember(ctx, cached)
+	return values, err
+}
+
+// Set implements the Store.Set method.
+func (cs *CachedStore) Set(ctx context.Context, k, v string) error {
+	if err := cs.store.Set(ctx, k, v); err != nil {
+		return err
+	}
+	return cs.invalidate(ctx, k)
+}
+
+// SetBytes implements the ByteStore.SetBytes method.
+func (cs *CachedStore) SetBytes(ctx context.Context, k string, v []byte) error {
+	if err := SetBytes(ctx, cs.store, k, v); err != nil {
+		return err
+	}
+	return cs.invalidate(ctx, k)
+}
+
+// Delete implements the Store.Delete method.
+func (cs *CachedStore) Delete(ctx context.Context, k string) error {
+	if err := cs.store.Delete(ctx, k); err != nil {
+		return err
+	}
+	return cs.invalidate(ctx, k)
+}
+
+// List implements the Lister.List method, listing the wrapped store without caching.
+func (cs *CachedStore) List(ctx context.Context, prefix string) ([]string, error) {
+	lister, ok := cs.store.(Lister)
+	if !ok {
+		return nil, errors.New("store does not support listing")
+	}
+	return lister.List(ctx, prefix)
+}
+
+// Close closes the wrapped store, if it can be closed.
+func (cs *CachedStore) Close() error {
+	if c, ok := cs.store.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,335 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+func testCache(t *testing.T) *FileStore {
+	t.Helper()
+	cache, err := NewFileStore(filepath.Join(t.TempDir(), "cache"))
+	require.NoError(t, err)
+	return cache
+}
+
+func TestCachedStore_Get(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("read through", func(t *testing.T) {
+		store := NewMockStore(t)
+		store.EXPECT().Get(mock.Anything, "foo").Return("bar", nil).Once()
+		cache := testCache(t)
+		cs := NewCachedStore(store, cache, "test", time.Hour)
+
+		for range 2 {
+			v, err := cs.Get(ctx, "foo")
+			require.NoError(t, err)
+			assert.Equal(t, "bar", v)
+		}
+
+		raw, err := cache.Get(ctx, "cache:test/foo")
+		require.NoError(t, err)
+		var entry cacheEntry
+		require.NoError(t, json.Unmarshal([]byte(raw), &entry))
+		assert.Equal(t, []byte("bar"), entry.Value)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), entry.Expires, time.Minute)
+		assert.Equal(t, []string{"foo"}, keys(t, cs))
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		store := NewMockStore(t)
+		store.EXPECT().Get(mock.Anything, "foo").Return("new", nil).Once()
+		cache := testCache(t)
+		past := time.Now().Add(-time.Second)
+		expired, _ := json.Marshal(cacheEntry{Value: []byte("old"), Expires: past})
+		index, _ := json.Marshal(map[string]time.Time{"foo": past, "baz": past})
+		require.NoError(t, cache.Set(ctx, "cache:test/foo", string(expired)))
+		require.NoError(t, cache.Set(ctx, "cache:test/baz", string(expired)))
+		require.NoError(t, cache.Set(ctx, "cache:test", string(index)))
+		cs := NewCachedStore(store, cache, "test", time.Hour)
+
+		v, err := cs.Get(ctx, "foo")
+		require.NoError(t, err)
+		assert.Equal(t, "new", v)
+		assert.Equal(t, []string{"foo"}, keys(t, cs), "expired entries are dropped")
+		_, err = cache.Get(ctx, "cache:test/baz")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		store := NewMockStore(t)
+		store.EXPECT().Get(mock.Anything, "foo").Return("", ErrKeyNotFound).Twice()
+		cs := NewCachedStore(store, testCache(t), "test", time.Hour)
+
+		for range 2 {
+			_, err := cs.Get(ctx, "foo")
+			assert.ErrorIs(t, err, ErrKeyNotFound)
+		}
+	})
+
+	t.Run("broken cache", func(t *testing.T) {
+		store := NewMockStore(t)
+		store.EXPECT().Get(mock.Anything, "foo").Return("bar", nil).Twice()
+		cache := NewMockStore(t)
+		cache.EXPECT().Get(mock.Anything, "cache:test/foo").Return("", errors.New("locked"))
+		cache.EXPECT().Set(mock.Anything, "cache:test/foo", mock.Anything).Return(errors.New("too big"))
+		cs := NewCachedStore(store, cache, "test", time.Hour)
+		var stderr bytes.Buffer
+		cs.stderr = &stderr
+
+		for range 2 {
+			v, err := cs.Get(ctx, "foo")
+			require.NoError(t, err)
+			assert.Equal(t, "bar", v)
+		}
+		assert.Equal(t, "warning: could not cache values of test store: foo: too big\n", stderr.String(), "reported once")
+	})
+
+	t.Run("escaped store name", func(t *testing.T) {
+		store := NewMockStore(t)
+		store.EXPECT().Get(mock.Anything, "c").Return("bar", nil).Once()
+		cache := testCache(t)
+		cs := NewCachedStore(store, cache, "a/b", time.Hour)
+
+		_, err := cs.Get(ctx, "c")
+		require.NoError(t, err)
+		_, err = cache.Get(ctx, "cache:a%2Fb/c")
+		assert.NoError(t, err)
+		_, ok := NewCachedStore(NewMockStore(t), cache, "a", time.Hour).lookup(ctx, "b/c")
+		assert.False(t, ok, "stores don't share entries")
+	})
+}
+
+// keys returns the keys cached by cs.
+func keys(t *testing.T, cs *CachedStore) []string {
+	t.Helper()
+	var keys []string
+	for k := range readCacheIndex(context.TODO(), cs.cache, cs.name) {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestCachedStore_GetBytes(t *testing.T) {
+	ctx := context.TODO()
+
+	inner, err := NewFileStore(filepath.Join(t.TempDir(), "store"))
+	require.NoError(t, err)
+	require.NoError(t, inner.SetBytes(ctx, "text", []byte("hello")))
+	require.NoError(t, inner.SetBytes(ctx, "bin", []byte{0xff, 0x00}))
+	cache := testCache(t)
+	want := map[string][]byte{"text": []byte("hello"), "bin": {0xff, 0x00}}
+
+	// Cached by the string reads of clef exec
+	cs := NewCachedStore(inner, cache, "test", time.Hour)
+	_, err = cs.BatchGet(ctx, []string{"text"})
+	require.NoError(t, err)
+	_, err = cs.Get(ctx, "bin")
+	require.NoError(t, err)
+
+	// Read back from cache only
+	cs = NewCachedStore(NewMockStore(t), cache, "test", time.Hour)
+	for k, want := range want {
+		v, err := cs.GetBytes(ctx, k)
+		require.NoError(t, err)
+		assert.Equal(t, want, v, "binary values survive the cache")
+	}
+}
+
+func TestSetBytes(t *testing.T) {
+	t.Run("text store", func(t *testing.T) {
+		store := NewMockStore(t)
+		store.EXPECT().Set(mock.Anything, "k", "text").Return(nil).Once()
+
+		assert.NoError(t, SetBytes(context.TODO(), store, "k", []byte("text")))
+		assert.ErrorIs(t, SetBytes(context.TODO(), store, "k", []byte{0xff}), ErrBinary)
+	})
+}
+
+func TestCachedStore_GetVersion(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("not cached", func(t *testing.T) {
+		getter := NewMockVersionGetter(t)
+		getter.EXPECT().GetVersion(mock.Anything, "foo", "3").Return("old", nil).Twice()
+		store := struct {
+			Store
+			VersionGetter
+		}{NewMockStore(t), getter}
+		// The cache must not be used
+		cs := NewCachedStore(store, NewMockStore(t), "test", time.Hour)
+
+		for range 2 {
+			v, err := cs.GetVersion(ctx, "foo", "3")
+			require.NoError(t, err)
+			assert.Equal(t, "old", v)
+		}
+	})
+
+	t.Run("unversioned", func(t *testing.T) {
+		cs := NewCachedStore(NewMockStore(t), NewMockStore(t), "test", time.Hour)
+
+		_, err := cs.GetVersion(ctx, "foo", "3")
+		assert.ErrorIs(t, err, ErrUnversioned)
+	})
+}
+
+func TestCachedStore_Rollback(t *testing.T) {
+	ctx := context.TODO()
+
+	store := NewMockStore(t)
+	store.EXPECT().Get(mock.Anything, "foo").Return("new", nil).Once()
+	store.EXPECT().Get(mock.Anything, "foo").Return("old", nil).Once()
+	versioned := NewMockVersioned(t)
+	versioned.EXPECT().Rollback(mock.Anything, "foo", "1").Return(nil).Once()
+	cs := NewCachedStore(struct {
+		Store
+		Versioned
+	}{store, versioned}, testCache(t), "test", time.Hour)
+
+	v, err := cs.Get(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "new", v)
+	require.NoError(t, cs.Rollback(ctx, "foo", "1"))
+	v, err = cs.Get(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "old", v, "rollback invalidates the key")
+}
+
+func TestCachedStore_BatchGet(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("batch store", func(t *testing.T) {
+		getter := NewMockBatchGetter(t)
+		getter.EXPECT().BatchGet(mock.Anything, []string{"a", "b"}).Return(map[string]string{"a": "1"}, nil).Once()
+		getter.EXPECT().BatchGet(mock.Anything, []string{"b"}).Return(map[string]string{}, nil).Once()
+		store := struct {
+			Store
+			BatchGetter
+		}{NewMockStore(t), getter}
+		cs := NewCachedStore(store, testCache(t), "test", time.Hour)
+
+		for range 2 {
+			values, err := cs.BatchGet(ctx, []string{"a", "b"})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"a": "1"}, values)
+		}
+	})
+
+	t.Run("one by one", func(t *testing.T) {
+		store := NewMockStore(t)
+		store.EXPECT().Get(mock.Anything, "a").Return("1", nil).Once()
+		store.EXPECT().Get(mock.Anything, "b").Return("", ErrKeyNotFound).Once()
+		store.EXPECT().Get(mock.Anything, "c").Return("", errors.New("denied")).Once()
+		cs := NewCachedStore(store, testCache(t), "test", time.Hour)
+
+		values, err := cs.BatchGet(ctx, []string{"a", "b", "c"})
+		assert.Equal(t, map[string]string{"a": "1"}, values)
+		assert.EqualError(t, err, "get c: denied")
+
+		values, err = cs.BatchGet(ctx, []string{"a"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"a": "1"}, values, "cached")
+	})
+}
+
+func TestCachedStore_write(t *testing.T) {
+	ctx := context.TODO()
+	store := NewMockStore(t)
+	store.EXPECT().Get(mock.Anything, mock.Anything).Return("old", nil)
+	store.EXPECT().Set(mock.Anything, "foo", "new").Return(nil).Once()
+	store.EXPECT().Delete(mock.Anything, "bar").Return(nil).Once()
+	cache := testCache(t)
+	cs := NewCachedStore(store, cache, "test", time.Hour)
+
+	for _, k := range []string{"foo", "bar", "baz"} {
+		_, err := cs.Get(ctx, k)
+		require.NoError(t, err)
+	}
+	require.NoError(t, cs.Set(ctx, "foo", "new"))
+	require.NoError(t, cs.Delete(ctx, "bar"))
+	assert.Equal(t, []string{"baz"}, keys(t, cs))
+
+	store.EXPECT().Delete(mock.Anything, "baz").Return(errors.New("denied")).Once()
+	assert.EqualError(t, cs.Delete(ctx, "baz"), "denied")
+	assert.Equal(t, []string{"baz"}, keys(t, cs), "failed writes keep the cache")
+}
+
+func TestCachedStore_List(t *testing.T) {
+	lister := NewMockLister(t)
+	lister.EXPECT().List(mock.Anything, "f").Return([]string{"foo"}, nil).Once()
+	store := struct {
+		Store
+		Lister
+	}{NewMockStore(t), lister}
+
+	keys, err := NewCachedStore(store, testCache(t), "test", time.Hour).List(context.TODO(), "f")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo"}, keys)
+
+	_, err = NewCachedStore(NewMockStore(t), testCache(t), "test", time.Hour).List(context.TODO(), "")
+	assert.EqualError(t, err, "store does not support listing")
+}
+
+func TestClearCache(t *testing.T) {
+	ctx := context.TODO()
+	keyring.MockInit()
+
+	store := NewMockStore(t)
+	store.EXPECT().Get(mock.Anything, "foo").Return("bar", nil).Twice()
+	cs := NewCachedStore(store, SystemStore, "test", time.Hour)
+	_, err := cs.Get(ctx, "foo")
+	require.NoError(t, err)
+
+	require.NoError(t, ClearCache(ctx, SystemStore, "test"))
+	_, err = SystemStore.Get(ctx, "cache:test/foo")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	require.NoError(t, ClearCache(ctx, SystemStore, "test"), "clearing twice is fine")
+	_, err = cs.Get(ctx, "foo")
+	require.NoError(t, err)
+}
+
+func TestOpenCache(t *testing.T) {
+	ctx := context.TODO()
+	keyring.MockInit()
+
+	cache, err := OpenCache(ctx, "")
+	require.NoError(t, err)
+	assert.Same(t, SystemStore, cache)
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	xdg.Reload()
+	defer xdg.Reload()
+
+	cache, err = OpenCache(ctx, "file")
+	require.NoError(t, err)
+	require.NoError(t, cache.Set(ctx, "cache:test", "{}"))
+
+	// Reopened with the same identity
+	cache, err = OpenCache(ctx, "file")
+	require.NoError(t, err)
+	v, err := cache.Get(ctx, "cache:test")
+	require.NoError(t, err)
+	assert.Equal(t, "{}", v)
+
+	// A new identity starts a new cache
+	require.NoError(t, SystemStore.Delete(ctx, cacheIdentityKey))
+	cache, err = OpenCache(ctx, "file")
+	require.NoError(t, err)
+	_, err = cache.Get(ctx, "cache:test")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	_, err = OpenCache(ctx, "redis")
+	assert.EqualError(t, err, "unsupported cache backend 'redis'")
+}
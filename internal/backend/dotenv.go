@@ -0,0 +1,330 @@
+package backend
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/adrg/xdg"
+)
+
+func init() {
+	registerBuilder("dotenv", func() Builder { return new(DotenvStoreBuilder) })
+}
+
+// DotenvStoreBuilder implements the Builder interface for DotenvStore.
+type DotenvStoreBuilder struct {
+	// Path is the dotenv file, defaults to .env in the working directory.
+	Path string `toml:"path,omitempty"`
+	// ReadOnly refuses Set and Delete, leaving the file untouched.
+	ReadOnly bool `toml:"read-only,omitempty"`
+}
+
+// Build returns a new DotenvStore store.
+func (db *DotenvStoreBuilder) Build(_ context.Context, name string) (Store, error) {
+	return NewDotenvStore(firstNonEmpty(db.Path, ".env"), db.ReadOnly), nil
+}
+
+// DotenvStore reads and writes a dotenv (.env) file, where keys are variable names.
+//
+// Values may be unquoted, single quoted (literal) or double quoted (with \n, \r, \t, \" and \\ escapes),
+// and quoted values may span several lines. Variables are not expanded.
+// Writes only rewrite the value of the assigned variable: comments, blank lines, export prefixes
+// and the other entries are preserved as is. New variables are appended to the file.
+// A missing file is empty, and created on the first write.
+type DotenvStore struct {
+	path     string
+	readOnly bool
+}
+
+// NewDotenvStore creates a new DotenvStore backed by the file at filename.
+func NewDotenvStore(filename string, readOnly bool) *DotenvStore {
+	return &DotenvStore{filename, readOnly}
+}
+
+// dotenvLine is a logical line of a dotenv file, raw being its exact text, including the line break.
+// Lines assigning a variable have a key, and the value source text is raw[start:end].
+type dotenvLine struct {
+	raw        string
+	key        string
+	value      string
+	start, end int
+}
+
+func parseDotenv(data string) ([]dotenvLine, error) {
+	var lines []dotenvLine
+	for lineno := 1; data != ""; {
+		l, err := parseDotenvLine(data)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineno, err)
+		}
+		lines = append(lines, l)
+		lineno += strings.Count(l.raw, "\n")
+		data = data[len(l.raw):]
+	}
+	return lines, nil
+}
+
+// parseDotenvLine parses the logical line at the start of data.
+func parseDotenvLine(data string) (dotenvLine, error) {
+	eol := func(i int) int {
+		if j := strings.IndexByte(data[i:], '\n'); j >= 0 {
+			return i + j + 1
+		}
+		return len(data)
+	}
+
+	i := skipDotenvBlanks(data, 0)
+	if i == len(data) || strings.IndexByte("\r\n#", data[i]) >= 0 {
+		return dotenvLine{raw: data[:eol(i)]}, nil
+	}
+	if rest, ok := strings.CutPrefix(data[i:], "export"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+		i = skipDotenvBlanks(data, i+len("export"))
+	}
+	k := i
+	for i < len(data) && isDotenvKeyChar(data[i], i == k) {
+		i++
+	}
+	if i == k {
+		return dotenvLine{}, errors.New("invalid variable name")
+	}
+	l := dotenvLine{key: data[k:i]}
+	if i = skipDotenvBlanks(data, i); i == len(data) || data[i] != '=' {
+		return dotenvLine{}, fmt.Errorf("missing = after %s", l.key)
+	}
+	i = skipDotenvBlanks(data, i+1)
+	l.start = i
+
+	switch {
+	case i < len(data) && data[i] == '\'':
+		j := strings.IndexByte(data[i+1:], '\'')
+		if j < 0 {
+			return dotenvLine{}, fmt.Errorf("unterminated value of %s", l.key)
+		}
+		l.value = data[i+1 : i+1+j]
+		i += j + 2
+	case i < len(data) && data[i] == '"':
+		var b strings.Builder
+		for i++; ; i++ {
+			if i == len(data) {
+				return dotenvLine{}, fmt.Errorf("unterminated value of %s", l.key)
+			}
+			c := data[i]
+			if c == '"' {
+				i++
+				break
+			}
+			if c == '\\' && i+1 < len(data) {
+				i++
+				switch data[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 'r':
+					b.WriteByte('\r')
+				case 't':
+					b.WriteByte('\t')
+				case '"', '\\', '$':
+					b.WriteByte(data[i])
+				default:
+					b.WriteByte('\\')
+					b.WriteByte(data[i])
+				}
+				continue
+			}
+			b.WriteByte(c)
+		}
+		l.value = b.String()
+	default:
+		// Unquoted values end at the line break, or at a comment preceded by a blank
+		end := i
+		for j := i; j < len(data) && data[j] != '\n'; j++ {
+			if data[j] == '#' && (j == i || data[j-1] == ' ' || data[j-1] == '\t') {
+				break
+			}
+			if data[j] != ' ' && data[j] != '\t' && data[j] != '\r' {
+				end = j + 1
+			}
+		}
+		l.value = data[i:end]
+		i = end
+	}
+	l.end = i
+
+	if i = skipDotenvBlanks(data, i); i < len(data) && strings.IndexByte("\r\n#", data[i]) < 0 {
+		return dotenvLine{}, fmt.Errorf("unexpected characters after the value of %s", l.key)
+	}
+	l.raw = data[:eol(i)]
+	return l, nil
+}
+
+func skipDotenvBlanks(data string, i int) int {
+	for i < len(data) && (data[i] == ' ' || data[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+func isDotenvKeyChar(c byte, first bool) bool {
+	switch {
+	case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		return true
+	case '0' <= c && c <= '9', c == '.', c == '-':
+		return !first
+	}
+	return false
+}
+
+func isDotenvKey(k string) bool {
+	for i := range len(k) {
+		if !isDotenvKeyChar(k[i], i == 0) {
+			return false
+		}
+	}
+	return k != ""
+}
+
+// quoteDotenv returns v as a dotenv value, quoted only when needed.
+// Single quotes are preferred, as they are read literally by every dotenv implementation.
+func quoteDotenv(v string) string {
+	safe := func(r rune) bool {
+		return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || strings.ContainsRune("_./:@%+,-=", r)
+	}
+	if !strings.ContainsFunc(v, func(r rune) bool { return !safe(r) }) {
+		return v
+	}
+	if !strings.ContainsAny(v, "'\r\n") {
+		return "'" + v + "'"
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`, "\r", `\r`).Replace(v) + `"`
+}
+
+func (ds *DotenvStore) read() ([]dotenvLine, error) {
+	data, err := os.ReadFile(ds.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	lines, err := parseDotenv(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", ds.path, err)
+	}
+	return lines, nil
+}
+
+// dotenvLockPath returns the lock file of the dotenv file filename. It lives in the user cache directory,
+// keyed by the absolute path of filename, as a sidecar would be left in the project next to the .env.
+func dotenvLockPath(filename string) (string, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(abs))
+	lockname, err := xdg.CacheFile(filepath.Join("clef", "locks", hex.EncodeToString(sum[:16])+".lock"))
+	if err != nil {
+		return "", fmt.Errorf("locate lock file: %w", err)
+	}
+	return lockname, nil
+}
+
+// update applies f to the file lines, holding the store lock from read to write.
+func (ds *DotenvStore) update(f func([]dotenvLine) []dotenvLine) error {
+	if ds.readOnly {
+		return ErrReadOnly
+	}
+	lockname, err := dotenvLockPath(ds.path)
+	if err != nil {
+		return err
+	}
+	unlock, err := lockPath(lockname)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	lines, err := ds.read()
+	if err != nil {
+		return err
+	}
+	updated := f(lines)
+	if lines == nil && len(updated) == 0 {
+		// Nothing to write, don't create an empty file
+		return nil
+	}
+	var b strings.Builder
+	for _, l := range updated {
+		b.WriteString(l.raw)
+	}
+	return writeFileAtomic(ds.path, []byte(b.String()))
+}
+
+// Get implements the Store.Get method.
+// When a variable is assigned several times, the last assignment wins.
+func (ds *DotenvStore) Get(ctx context.Context, k string) (string, error) {
+	lines, err := ds.read()
+	if err != nil {
+		return "", err
+	}
+	for _, l := range slices.Backward(lines) {
+		if l.key == k {
+			return l.value, nil
+		}
+	}
+	return "", ErrKeyNotFound
+}
+
+// Set implements the Store.Set method.
+// Every assignment of the variable is updated in place.
+func (ds *DotenvStore) Set(ctx context.Context, k, v string) error {
+	if !isDotenvKey(k) {
+		return fmt.Errorf("invalid variable name %s", k)
+	}
+	value := quoteDotenv(v)
+	return ds.update(func(lines []dotenvLine) []dotenvLine {
+		found := false
+		for i, l := range lines {
+			if l.key == k {
+				lines[i].raw = l.raw[:l.start] + value + l.raw[l.end:]
+				found = true
+			}
+		}
+		if found {
+			return lines
+		}
+		if n := len(lines); n > 0 && !strings.HasSuffix(lines[n-1].raw, "\n") {
+			lines[n-1].raw += "\n"
+		}
+		return append(lines, dotenvLine{raw: k + "=" + value + "\n"})
+	})
+}
+
+// Delete implements the Store.Delete method.
+func (ds *DotenvStore) Delete(ctx context.Context, k string) error {
+	return ds.update(func(lines []dotenvLine) []dotenvLine {
+		return slices.DeleteFunc(lines, func(l dotenvLine) bool { return l.key == k })
+	})
+}
+
+// List implements the Lister.List method.
+func (ds *DotenvStore) List(ctx context.Context, prefix string) ([]string, error) {
+	lines, err := ds.read()
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, l := range lines {
+		if l.key != "" && strings.HasPrefix(l.key, prefix) && !slices.Contains(keys, l.key) {
+			keys = append(keys, l.key)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
@@ -0,0 +1,279 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/adrg/xdg"
+)
+
+// PassphraseEnv is the environment variable read for an encrypted store passphrase.
+const PassphraseEnv = "CLEF_PASSPHRASE"
+
+func init() {
+	registerBuilder("encryptedfile", func() Builder { return new(EncryptedFileStoreBuilder) })
+}
+
+// EncryptedFileStoreBuilder implements the Builder interface for EncryptedFileStore.
+//
+// The store is sealed either with an age X25519 identity file, or with a passphrase.
+// The passphrase is read from the CLEF_PASSPHRASE environment variable,
+// from the system store if cached, or prompted on the terminal.
+type EncryptedFileStoreBuilder struct {
+	Path string `toml:"path"`
+	// Identity is the path to an age identity file, as generated by age-keygen.
+	Identity string `toml:"identity,omitempty"`
+	// CachePassphrase caches the passphrase in the system store once unlocked.
+	CachePassphrase bool `toml:"cache-passphrase,omitempty"`
+}
+
+// Build returns a new EncryptedFileStore store.
+func (eb *EncryptedFileStoreBuilder) Build(ctx context.Context, name string) (Store, error) {
+	if eb.Path == "" {
+		xdgp, err := xdg.DataFile(path.Join("clef", "stores", name+".age"))
+		if err != nil {
+			return nil, fmt.Errorf("could not load file for %s: %w", name, err)
+		}
+		eb.Path = xdgp
+	}
+
+	if eb.Identity != "" {
+		identity, err := loadX25519Identity(eb.Identity)
+		if err != nil {
+			return nil, err
+		}
+		return NewEncryptedFileStore(eb.Path, identity, identity.Recipient())
+	}
+
+	return eb.unlock(ctx, name)
+}
+
+// unlock opens the store sealed with a passphrase. A prompted passphrase is checked against the store,
+// or confirmed if the store is new, before it is cached: a typo would be replayed, or seal the store.
+func (eb *EncryptedFileStoreBuilder) unlock(ctx context.Context, name string) (*EncryptedFileStore, error) {
+	if p := os.Getenv(PassphraseEnv); p != "" {
+		return newScryptStore(eb.Path, p)
+	}
+
+	cacheKey := "encryptedfile:" + name
+	if eb.CachePassphrase {
+		if p, err := SystemStore.Get(ctx, cacheKey); err == nil {
+			return newScryptStore(eb.Path, p)
+		}
+	}
+
+	p, err := promptSecret(fmt.Sprintf("Passphrase for %s store: ", name))
+	if err != nil {
+		return nil, fmt.Errorf("no passphrase for %s: %w (set %s)", name, err, PassphraseEnv)
+	}
+	store, err := newScryptStore(eb.Path, p)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(eb.Path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() == 0 {
+		confirm, err := promptSecret("Confirm passphrase: ")
+		if err != nil {
+			return nil, fmt.Errorf("no passphrase for %s: %w (set %s)", name, err, PassphraseEnv)
+		}
+		if confirm != p {
+			return nil, errors.New("passphrases don't match")
+		}
+	} else if _, err := store.read(); err != nil {
+		return nil, err
+	}
+
+	if eb.CachePassphrase {
+		if err := SystemStore.Set(ctx, cacheKey, p); err != nil {
+			return nil, fmt.Errorf("cache passphrase: %w", err)
+		}
+	}
+	return store, nil
+}
+
+// newScryptStore opens the EncryptedFileStore at filename, sealed with passphrase.
+func newScryptStore(filename, passphrase string) (*EncryptedFileStore, error) {
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return NewEncryptedFileStore(filename, identity, recipient)
+}
+
+func loadX25519Identity(filename string) (*age.X25519Identity, error) {
+	fd, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("open identity: %w", err)
+	}
+	defer fd.Close()
+
+	identities, err := age.ParseIdentities(fd)
+	if err != nil {
+		return nil, fmt.Errorf("parse identity: %w", err)
+	}
+	for _, i := range identities {
+		if x, ok := i.(*age.X25519Identity); ok {
+			return x, nil
+		}
+	}
+	return nil, fmt.Errorf("no X25519 identity in %s", filename)
+}
+
+// EncryptedFileStore uses an age encrypted file to store and retrieve values.
+// The whole key/value map is sealed, so neither keys nor values are readable at rest.
+// Like FileStore, writes are atomic and serialized across processes.
+type EncryptedFileStore struct {
+	path      string
+	identity  age.Identity
+	recipient age.Recipient
+}
+
+// NewEncryptedFileStore creates a new EncryptedFileStore baked by the file at filename.
+// It will create the file if it doesn't exist.
+func NewEncryptedFileStore(filename string, identity age.Identity, recipient age.Recipient) (*EncryptedFileStore, error) {
+	fd, err := os.OpenFile(filename, os.O_RDONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncryptedFileStore{filename, identity, recipient}, fd.Close()
+}
+
+// Close closes the store.
+// The underlying file is only opened for the duration of each operation.
+func (es *EncryptedFileStore) Close() error {
+	return nil
+}
+
+// Get implements the Store.Get method.
+func (es *EncryptedFileStore) Get(ctx context.Context, k string) (string, error) {
+	m, err := es.read()
+	if err != nil {
+		return "", err
+	}
+	v, ok := m[k]
+	if !ok {
+		return "", ErrKeyNotFound
+	}
+	return v, nil
+}
+
+// BatchGet implements the BatchGetter.BatchGet method, decrypting the file once.
+func (es *EncryptedFileStore) BatchGet(ctx context.Context, keys []string) (map[string]string, error) {
+	m, err := es.read()
+	if err != nil {
+		return nil, err
+	}
+	return pick(m, keys), nil
+}
+
+// Set implements the Store.Set method
+func (es *EncryptedFileStore) Set(ctx context.Context, k, v string) error {
+	return es.update(func(m map[string]string) {
+		m[k] = v
+	})
+}
+
+// GetBytes implements the ByteStore.GetBytes method.
+func (es *EncryptedFileStore) GetBytes(ctx context.Context, k string) ([]byte, error) {
+	v, err := es.Get(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(v), nil
+}
+
+// SetBytes implements the ByteStore.SetBytes method.
+func (es *EncryptedFileStore) SetBytes(ctx context.Context, k string, v []byte) error {
+	return es.Set(ctx, k, string(v))
+}
+
+// Delete implements the Store.Delete method
+func (es *EncryptedFileStore) Delete(ctx context.Context, k string) error {
+	return es.update(func(m map[string]string) {
+		delete(m, k)
+	})
+}
+
+// List implements the Lister.List method.
+func (es *EncryptedFileStore) List(ctx context.Context, prefix string) ([]string, error) {
+	m, err := es.read()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+func (es *EncryptedFileStore) read() (map[string]string, error) {
+	sealed, err := os.ReadFile(es.path)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) == 0 {
+		return make(map[string]string), nil
+	}
+
+	r, err := age.Decrypt(bytes.NewReader(sealed), es.identity)
+	if err != nil {
+		var nomatch *age.NoIdentityMatchError
+		if errors.As(err, &nomatch) {
+			return nil, fmt.Errorf("unlock store: wrong passphrase or identity")
+		}
+		return nil, fmt.Errorf("unlock store: %w", err)
+	}
+	plain, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("unlock store: %w", err)
+	}
+	return decodeBinaryMap(bytes.NewReader(plain))
+}
+
+// update applies f to the stored map, holding the store lock from read to write.
+func (es *EncryptedFileStore) update(f func(map[string]string)) error {
+	unlock, err := lockFile(es.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	m, err := es.read()
+	if err != nil {
+		return err
+	}
+	f(m)
+
+	var sealed bytes.Buffer
+	w, err := age.Encrypt(&sealed, es.recipient)
+	if err != nil {
+		return fmt.Errorf("seal store: %w", err)
+	}
+	if err := encodeBinaryMap(w, m); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("seal store: %w", err)
+	}
+	return writeFileAtomic(es.path, sealed.Bytes())
+}
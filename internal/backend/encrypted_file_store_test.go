@@ -0,0 +1,184 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+func newTestScryptStore(t *testing.T, filename, passphrase string) *EncryptedFileStore {
+	t.Helper()
+
+	identity, err := age.NewScryptIdentity(passphrase)
+	require.NoError(t, err)
+	recipient, err := age.NewScryptRecipient(passphrase)
+	require.NoError(t, err)
+	// Keep tests fast
+	recipient.SetWorkFactor(10)
+
+	es, err := NewEncryptedFileStore(filename, identity, recipient)
+	require.NoError(t, err)
+	t.Cleanup(func() { es.Close() })
+	return es
+}
+
+func TestEncryptedFileStoreBuilder_Build(t *testing.T) {
+	t.Run("identity", func(t *testing.T) {
+		identity, err := age.GenerateX25519Identity()
+		require.NoError(t, err)
+		idfile := path.Join(t.TempDir(), "identity.txt")
+		require.NoError(t, os.WriteFile(idfile, []byte(identity.String()+"\n"), 0600))
+
+		builder := &EncryptedFileStoreBuilder{Path: path.Join(t.TempDir(), "store"), Identity: idfile}
+		s, err := builder.Build(context.TODO(), "test")
+		if assert.NoError(t, err) {
+			defer s.(*EncryptedFileStore).Close()
+			assert.Implements(t, (*Lister)(nil), s)
+		}
+	})
+
+	t.Run("missing identity", func(t *testing.T) {
+		builder := &EncryptedFileStoreBuilder{Path: path.Join(t.TempDir(), "store"), Identity: "nope"}
+		_, err := builder.Build(context.TODO(), "test")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("passphrase from env", func(t *testing.T) {
+		t.Setenv(PassphraseEnv, "hunter2")
+
+		builder := &EncryptedFileStoreBuilder{Path: path.Join(t.TempDir(), "store")}
+		s, err := builder.Build(context.TODO(), "test")
+		if assert.NoError(t, err) {
+			s.(*EncryptedFileStore).Close()
+		}
+	})
+
+	t.Run("prompted passphrase is checked before caching", func(t *testing.T) {
+		keyring.MockInit()
+		filename := path.Join(t.TempDir(), "store")
+		require.NoError(t, newTestScryptStore(t, filename, "right").Set(context.TODO(), "k", "v"))
+		builder := &EncryptedFileStoreBuilder{Path: filename, CachePassphrase: true}
+
+		answerPrompts(t, "wrong")
+		_, err := builder.Build(context.TODO(), "test")
+		assert.EqualError(t, err, "unlock store: wrong passphrase or identity")
+		_, err = SystemStore.Get(context.TODO(), "encryptedfile:test")
+		assert.ErrorIs(t, err, ErrKeyNotFound, "typos are not cached")
+
+		answerPrompts(t, "right")
+		_, err = builder.Build(context.TODO(), "test")
+		require.NoError(t, err)
+		p, err := SystemStore.Get(context.TODO(), "encryptedfile:test")
+		require.NoError(t, err)
+		assert.Equal(t, "right", p)
+	})
+
+	t.Run("new store passphrase is confirmed", func(t *testing.T) {
+		builder := &EncryptedFileStoreBuilder{Path: path.Join(t.TempDir(), "store")}
+
+		answerPrompts(t, "hunter2", "hunter3")
+		_, err := builder.Build(context.TODO(), "test")
+		assert.EqualError(t, err, "passphrases don't match")
+
+		answerPrompts(t, "hunter2", "hunter2")
+		_, err = builder.Build(context.TODO(), "test")
+		assert.NoError(t, err)
+	})
+}
+
+// answerPrompts answers the next prompts with answers, in order.
+func answerPrompts(t *testing.T, answers ...string) {
+	t.Helper()
+	prompt := promptSecret
+	t.Cleanup(func() { promptSecret = prompt })
+	promptSecret = func(string) (string, error) {
+		if len(answers) == 0 {
+			return "", ErrNotInteractive
+		}
+		a := answers[0]
+		answers = answers[1:]
+		return a, nil
+	}
+}
+
+func TestEncryptedFileStore(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		filename := path.Join(t.TempDir(), "store")
+		es := newTestScryptStore(t, filename, "hunter2")
+
+		require.NoError(t, es.Set(context.TODO(), "key1", "value1"))
+		require.NoError(t, es.Set(context.TODO(), "key2", "value2"))
+		require.NoError(t, es.Delete(context.TODO(), "key2"))
+
+		v, err := es.Get(context.TODO(), "key1")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "value1", v)
+		}
+		_, err = es.Get(context.TODO(), "key2")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+		keys, err := es.List(context.TODO(), "")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"key1"}, keys)
+		}
+	})
+
+	t.Run("sealed at rest", func(t *testing.T) {
+		filename := path.Join(t.TempDir(), "store")
+		es := newTestScryptStore(t, filename, "hunter2")
+		require.NoError(t, es.Set(context.TODO(), "some-key", "some-value"))
+
+		content, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		assert.False(t, bytes.Contains(content, []byte("some-key")))
+		assert.False(t, bytes.Contains(content, []byte("some-value")))
+
+		info, err := os.Stat(filename)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		filename := path.Join(t.TempDir(), "store")
+		es := newTestScryptStore(t, filename, "hunter2")
+		require.NoError(t, es.Set(context.TODO(), "key1", "value1"))
+
+		other := newTestScryptStore(t, filename, "hunter3")
+		_, err := other.Get(context.TODO(), "key1")
+		assert.EqualError(t, err, "unlock store: wrong passphrase or identity")
+	})
+
+	t.Run("x25519 identity", func(t *testing.T) {
+		identity, err := age.GenerateX25519Identity()
+		require.NoError(t, err)
+
+		es, err := NewEncryptedFileStore(path.Join(t.TempDir(), "store"), identity, identity.Recipient())
+		require.NoError(t, err)
+		defer es.Close()
+
+		require.NoError(t, es.Set(context.TODO(), "key1", "value1"))
+		sealed, err := os.Open(es.path)
+		require.NoError(t, err)
+		defer sealed.Close()
+		r, err := age.Decrypt(sealed, identity)
+		require.NoError(t, err)
+		plain, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{
+			0x43, 0x4c, 0x45, 0x46, // Magic ("CLEF")
+			0x01,                   // Format version
+			0x04,                   // Key length (4 bytes for "key1")
+			0x6b, 0x65, 0x79, 0x31, // Key ("key1")
+			0x06,                               // Value length (6 bytes for "value1")
+			0x76, 0x61, 0x6c, 0x75, 0x65, 0x31, // Value ("value1")
+			0x52, 0x71, 0xc3, 0xf1, // Checksum
+		}, plain)
+	})
+}
@@ -0,0 +1,142 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"slices"
+)
+
+// Store files are laid out as follow:
+//
+//	magic    "CLEF"
+//	version  1 byte
+//	records  uvarint key length, key, uvarint value length, value (repeated, sorted by key)
+//	checksum CRC-32 (IEEE) of all the preceding bytes, little endian
+//
+// Files without the magic bytes are read with the legacy layout,
+// a headerless stream of uint16 length-prefixed records.
+// They are migrated to the current layout on the next write.
+const (
+	fileMagic         = "CLEF"
+	fileFormatVersion = 1
+
+	fileHeaderSize   = len(fileMagic) + 1
+	fileChecksumSize = crc32.Size
+)
+
+// CorruptionError is returned when a store file cannot be decoded.
+type CorruptionError struct {
+	// Offset is the position in the file at which decoding failed.
+	Offset int
+	// Reason describes the corruption.
+	Reason string
+}
+
+func (e *CorruptionError) Error() string {
+	return fmt.Sprintf("corrupted store at offset %d: %s", e.Offset, e.Reason)
+}
+
+func encodeBinaryMap(w io.Writer, data map[string]string) error {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	buf := bytes.NewBufferString(fileMagic)
+	buf.WriteByte(fileFormatVersion)
+	for _, k := range keys {
+		buf.Write(binary.AppendUvarint(nil, uint64(len(k))))
+		buf.WriteString(k)
+		buf.Write(binary.AppendUvarint(nil, uint64(len(data[k]))))
+		buf.WriteString(data[k])
+	}
+	buf.Write(binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(buf.Bytes())))
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func decodeBinaryMap(r io.Reader) (map[string]string, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(content, []byte(fileMagic)) {
+		return decodeLegacyBinaryMap(content)
+	}
+	if len(content) < fileHeaderSize+fileChecksumSize {
+		return nil, &CorruptionError{len(content), "truncated file"}
+	}
+	if v := content[len(fileMagic)]; v != fileFormatVersion {
+		return nil, fmt.Errorf("unsupported store format version %d", v)
+	}
+
+	body := content[:len(content)-fileChecksumSize]
+	sum := binary.LittleEndian.Uint32(content[len(body):])
+	if crc32.ChecksumIEEE(body) != sum {
+		return nil, &CorruptionError{len(body), "checksum mismatch"}
+	}
+
+	data := make(map[string]string)
+	offset := fileHeaderSize
+	next := func() (string, error) {
+		l, n := binary.Uvarint(body[offset:])
+		if n <= 0 {
+			return "", &CorruptionError{offset, "invalid length"}
+		}
+		offset += n
+		if l > uint64(len(body)-offset) {
+			return "", &CorruptionError{offset, "record overflows file"}
+		}
+		s := string(body[offset : offset+int(l)])
+		offset += int(l)
+		return s, nil
+	}
+	for offset < len(body) {
+		key, err := next()
+		if err != nil {
+			return nil, err
+		}
+		value, err := next()
+		if err != nil {
+			return nil, err
+		}
+		data[key] = value
+	}
+	return data, nil
+}
+
+func decodeLegacyBinaryMap(content []byte) (map[string]string, error) {
+	data := make(map[string]string)
+
+	r := bytes.NewReader(content)
+	offset := func() int { return len(content) - r.Len() }
+	next := func() (string, error) {
+		var l uint16
+		if err := binary.Read(r, binary.LittleEndian, &l); err != nil {
+			return "", err
+		}
+		b := make([]byte, l)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	for r.Len() > 0 {
+		key, err := next()
+		if err != nil {
+			return nil, &CorruptionError{offset(), "truncated key"}
+		}
+		value, err := next()
+		if err != nil {
+			return nil, &CorruptionError{offset(), "truncated value"}
+		}
+		data[key] = value
+	}
+	return data, nil
+}
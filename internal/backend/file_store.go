@@ -1,11 +1,13 @@
 package backend
 
 import (
+	"bytes"
 	"context"
-	"encoding/binary"
 	"fmt"
 	"os"
 	"path"
+	"slices"
+	"strings"
 
 	"github.com/adrg/xdg"
 )
@@ -33,29 +35,33 @@ func (fsb *FileStoreBuilder) Build(_ context.Context, name string) (Store, error
 // FIleStore uses a file to store and retrieve values.
 // FileStore is **not** recommended in a production environment.
 // Please use it only for testing purposes.
+//
+// Writes are atomic and serialized across processes with an advisory lock,
+// so a FileStore can be shared by concurrent clef invocations.
 type FileStore struct {
-	fd *os.File
+	path string
 }
 
 // NewFileStore creates a new FileStore baked by the file at filename.
 // It will create the file if it doesn't exist.
 func NewFileStore(filename string) (*FileStore, error) {
-	fd, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0644)
+	fd, err := os.OpenFile(filename, os.O_RDONLY|os.O_CREATE, 0644)
 	if err != nil {
 		return nil, err
 	}
 
-	return &FileStore{fd}, nil
+	return &FileStore{filename}, fd.Close()
 }
 
-// Close closes the filestore and its underlying file.
+// Close closes the filestore.
+// The underlying file is only opened for the duration of each operation.
 func (fs *FileStore) Close() error {
-	return fs.fd.Close()
+	return nil
 }
 
 // Get implements the Store.Get method.
 func (fs *FileStore) Get(ctx context.Context, k string) (string, error) {
-	m, err := readBinaryMap(fs.fd)
+	m, err := fs.read()
 	if err != nil {
 		return "", err
 	}
@@ -66,82 +72,106 @@ func (fs *FileStore) Get(ctx context.Context, k string) (string, error) {
 	return v, nil
 }
 
+// BatchGet implements the BatchGetter.BatchGet method, reading the file once.
+func (fs *FileStore) BatchGet(ctx context.Context, keys []string) (map[string]string, error) {
+	m, err := fs.read()
+	if err != nil {
+		return nil, err
+	}
+	return pick(m, keys), nil
+}
+
 // Set implements the Store.Set method
 func (fs *FileStore) Set(ctx context.Context, k, v string) error {
-	m, err := readBinaryMap(fs.fd)
+	return fs.update(func(m map[string]string) {
+		m[k] = v
+	})
+}
+
+// GetBytes implements the ByteStore.GetBytes method.
+func (fs *FileStore) GetBytes(ctx context.Context, k string) ([]byte, error) {
+	v, err := fs.Get(ctx, k)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	m[k] = v
-	return writeBinaryMap(fs.fd, m)
+	return []byte(v), nil
+}
+
+// SetBytes implements the ByteStore.SetBytes method.
+func (fs *FileStore) SetBytes(ctx context.Context, k string, v []byte) error {
+	return fs.Set(ctx, k, string(v))
 }
 
 // Delete implements the Store.Delete method
 func (fs *FileStore) Delete(ctx context.Context, k string) error {
-	m, err := readBinaryMap(fs.fd)
+	return fs.update(func(m map[string]string) {
+		delete(m, k)
+	})
+}
+
+// List implements the Lister.List method.
+func (fs *FileStore) List(ctx context.Context, prefix string) ([]string, error) {
+	m, err := fs.read()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	delete(m, k)
-	return writeBinaryMap(fs.fd, m)
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
 }
 
-func writeBinaryMap(file *os.File, data map[string]string) error {
-	// Truncate the file before writing
-	if err := file.Truncate(0); err != nil {
+func (fs *FileStore) read() (map[string]string, error) {
+	fd, err := os.Open(fs.path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	return readBinaryMap(fd)
+}
+
+// update applies f to the stored map, holding the store lock from read to write.
+func (fs *FileStore) update(f func(map[string]string)) error {
+	unlock, err := lockFile(fs.path)
+	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	if _, err := file.Seek(0, 0); err != nil {
+	m, err := fs.read()
+	if err != nil {
 		return err
 	}
-	for key, value := range data {
-		keyLen := uint16(len(key))
-		valueLen := uint16(len(value))
+	f(m)
+	return writeBinaryMap(fs.path, m)
+}
 
-		// Write key length + key bytes
-		if err := binary.Write(file, binary.LittleEndian, keyLen); err != nil {
-			return err
-		}
-		if _, err := file.Write([]byte(key)); err != nil {
-			return err
+// pick returns the entries of m with the given keys.
+func pick(m map[string]string, keys []string) map[string]string {
+	values := make(map[string]string, len(keys))
+	for _, k := range keys {
+		if v, ok := m[k]; ok {
+			values[k] = v
 		}
+	}
+	return values
+}
 
-		// Write value length + value bytes
-		if err := binary.Write(file, binary.LittleEndian, valueLen); err != nil {
-			return err
-		}
-		if _, err := file.Write([]byte(value)); err != nil {
-			return err
-		}
+func writeBinaryMap(filename string, data map[string]string) error {
+	var buf bytes.Buffer
+	if err := encodeBinaryMap(&buf, data); err != nil {
+		return err
 	}
-	return nil
+	return writeFileAtomic(filename, buf.Bytes())
 }
 
 func readBinaryMap(file *os.File) (map[string]string, error) {
-	data := make(map[string]string)
-
-	file.Seek(0, 0)
-	for {
-		var keyLen uint16
-		if err := binary.Read(file, binary.LittleEndian, &keyLen); err != nil {
-			break
-		}
-		key := make([]byte, keyLen)
-		if _, err := file.Read(key); err != nil {
-			return nil, err
-		}
-
-		var valueLen uint16
-		if err := binary.Read(file, binary.LittleEndian, &valueLen); err != nil {
-			return nil, err
-		}
-		value := make([]byte, valueLen)
-		if _, err := file.Read(value); err != nil {
-			return nil, err
-		}
-
-		data[string(key)] = string(value)
+	if _, err := file.Seek(0, 0); err != nil {
+		return nil, err
 	}
-	return data, nil
+	return decodeBinaryMap(file)
 }
@@ -2,9 +2,11 @@ package backend
 
 import (
 	"context"
-	"io"
+	"fmt"
 	"os"
 	"path"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -19,7 +21,7 @@ func TestWriteBinaryMap(t *testing.T) {
 		f, err := os.CreateTemp(t.TempDir(), "")
 		require.NoError(t, err)
 
-		assert.NoError(t, writeBinaryMap(f, map[string]string{}))
+		assert.NoError(t, writeBinaryMap(f.Name(), map[string]string{}))
 	})
 
 	t.Run("non empty map", func(t *testing.T) {
@@ -27,21 +29,35 @@ func TestWriteBinaryMap(t *testing.T) {
 		f, err := os.CreateTemp(t.TempDir(), "")
 		require.NoError(t, err)
 
-		assert.NoError(t, writeBinaryMap(f, map[string]string{"key1": "value1"}))
-		f.Seek(0, 0)
-		content, err := io.ReadAll(f)
+		assert.NoError(t, writeBinaryMap(f.Name(), map[string]string{"key1": "value1"}))
+		content, err := os.ReadFile(f.Name())
 		assert.NoError(t, err)
 		assert.Equal(t, []byte{
-			0x04, 0x00, // Key length (4 bytes for "key1")
+			0x43, 0x4c, 0x45, 0x46, // Magic ("CLEF")
+			0x01,                   // Format version
+			0x04,                   // Key length (4 bytes for "key1")
 			0x6b, 0x65, 0x79, 0x31, // Key ("key1")
-			0x06, 0x00, // Value length (6 bytes for "value1")
+			0x06,                               // Value length (6 bytes for "value1")
 			0x76, 0x61, 0x6c, 0x75, 0x65, 0x31, // Value ("value1")
+			0x52, 0x71, 0xc3, 0xf1, // Checksum
 		}, content)
 	})
 
-	t.Run("nil file", func(t *testing.T) {
+	t.Run("preserve permissions", func(t *testing.T) {
+		t.Parallel()
+		filename := path.Join(t.TempDir(), "store")
+		require.NoError(t, os.WriteFile(filename, nil, 0640))
+
+		assert.NoError(t, writeBinaryMap(filename, map[string]string{"key1": "value1"}))
+		info, err := os.Stat(filename)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
 		t.Parallel()
-		assert.EqualError(t, writeBinaryMap(nil, map[string]string{"foo": "bar"}), "invalid argument")
+		err := writeBinaryMap(path.Join(t.TempDir(), "nope", "store"), map[string]string{"foo": "bar"})
+		assert.ErrorIs(t, err, os.ErrNotExist)
 	})
 }
 
@@ -61,7 +77,7 @@ func TestReadBinaryMap(t *testing.T) {
 	t.Run("nil file", func(t *testing.T) {
 		t.Parallel()
 		m, err := readBinaryMap(nil)
-		assert.NoError(t, err)
+		assert.ErrorIs(t, err, os.ErrInvalid)
 		assert.Empty(t, m)
 	})
 
@@ -83,6 +99,108 @@ func TestReadBinaryMap(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, map[string]string{"key1": "value1"}, m)
 	})
+
+	t.Run("versioned", func(t *testing.T) {
+		t.Parallel()
+		content := []byte{
+			0x43, 0x4c, 0x45, 0x46, // Magic ("CLEF")
+			0x01,                   // Format version
+			0x04,                   // Key length (4 bytes for "key1")
+			0x6b, 0x65, 0x79, 0x31, // Key ("key1")
+			0x06,                               // Value length (6 bytes for "value1")
+			0x76, 0x61, 0x6c, 0x75, 0x65, 0x31, // Value ("value1")
+			0x52, 0x71, 0xc3, 0xf1, // Checksum
+		}
+		f, err := os.CreateTemp(t.TempDir(), "")
+		require.NoError(t, err)
+		_, err = f.Write(content)
+		require.NoError(t, err)
+
+		m, err := readBinaryMap(f)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"key1": "value1"}, m)
+	})
+
+	t.Run("large value", func(t *testing.T) {
+		t.Parallel()
+		f, err := os.CreateTemp(t.TempDir(), "")
+		require.NoError(t, err)
+
+		large := strings.Repeat("x", 1<<17)
+		require.NoError(t, writeBinaryMap(f.Name(), map[string]string{"key1": large, "key2": "value2"}))
+		f, err = os.Open(f.Name())
+		require.NoError(t, err)
+		defer f.Close()
+		m, err := readBinaryMap(f)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"key1": large, "key2": "value2"}, m)
+	})
+
+	corrupted := map[string]struct {
+		content []byte
+		offset  int
+		reason  string
+	}{
+		"legacy truncated key": {
+			[]byte{0x04, 0x00, 0x6b, 0x65},
+			4, "truncated key",
+		},
+		"legacy truncated value": {
+			[]byte{0x04, 0x00, 0x6b, 0x65, 0x79, 0x31, 0x06, 0x00, 0x76},
+			9, "truncated value",
+		},
+		"truncated file": {
+			[]byte{0x43, 0x4c, 0x45, 0x46, 0x01, 0x3f, 0xa5},
+			7, "truncated file",
+		},
+		"checksum mismatch": {
+			[]byte{
+				0x43, 0x4c, 0x45, 0x46, // Magic ("CLEF")
+				0x01,                   // Format version
+				0x04,                   // Key length (4 bytes for "key1")
+				0x6b, 0x65, 0x79, 0x31, // Key ("key1")
+				0x06,                               // Value length (6 bytes for "value2")
+				0x76, 0x61, 0x6c, 0x75, 0x65, 0x32, // Value ("value2")
+				0x52, 0x71, 0xc3, 0xf1, // Checksum
+			},
+			17, "checksum mismatch",
+		},
+		"record overflow": {
+			[]byte{
+				0x43, 0x4c, 0x45, 0x46, 0x01, // Header
+				0x04, 0x6b, 0x65, // Truncated key
+				0x5e, 0xdb, 0xd9, 0xcd, // Checksum
+			},
+			6, "record overflows file",
+		},
+	}
+	for name, tc := range corrupted {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			f, err := os.CreateTemp(t.TempDir(), "")
+			require.NoError(t, err)
+			_, err = f.Write(tc.content)
+			require.NoError(t, err)
+
+			_, err = readBinaryMap(f)
+			var cerr *CorruptionError
+			if assert.ErrorAs(t, err, &cerr) {
+				assert.Equal(t, tc.offset, cerr.Offset)
+				assert.Equal(t, tc.reason, cerr.Reason)
+			}
+		})
+	}
+
+	t.Run("unsupported version", func(t *testing.T) {
+		t.Parallel()
+		f, err := os.CreateTemp(t.TempDir(), "")
+		require.NoError(t, err)
+		_, err = f.Write([]byte{0x43, 0x4c, 0x45, 0x46, 0x02, 0x00, 0x00, 0x00, 0x00})
+		require.NoError(t, err)
+
+		_, err = readBinaryMap(f)
+		assert.EqualError(t, err, "unsupported store format version 2")
+	})
 }
 
 func TestNewFileStore(t *testing.T) {
@@ -161,6 +279,20 @@ func TestFileStore_Get(t *testing.T) {
 	})
 }
 
+func TestFileStore_BatchGet(t *testing.T) {
+	filename := path.Join(t.TempDir(), "testfilestore_batchget")
+	fs, err := NewFileStore(filename)
+	require.NoError(t, err)
+	defer fs.Close()
+
+	require.NoError(t, writeBinaryMap(fs.path, map[string]string{"key1": "value1", "key2": "value2"}))
+
+	values, err := fs.BatchGet(context.TODO(), []string{"key1", "key3"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]string{"key1": "value1"}, values)
+	}
+}
+
 func TestFileStore_Set(t *testing.T) {
 	t.Run("new file", func(t *testing.T) {
 		filename := path.Join(t.TempDir(), "testfilestore_set_newfile")
@@ -170,14 +302,16 @@ func TestFileStore_Set(t *testing.T) {
 		defer fs.Close()
 
 		if assert.NoError(t, fs.Set(context.TODO(), "key1", "value1")) {
-			fs.fd.Seek(0, 0)
-			content, err := io.ReadAll(fs.fd)
+			content, err := os.ReadFile(fs.path)
 			require.NoError(t, err)
 			assert.Equal(t, []byte{
-				0x04, 0x00, // Key length (4 bytes for "key1")
+				0x43, 0x4c, 0x45, 0x46, // Magic ("CLEF")
+				0x01,                   // Format version
+				0x04,                   // Key length (4 bytes for "key1")
 				0x6b, 0x65, 0x79, 0x31, // Key ("key1")
-				0x06, 0x00, // Value length (6 bytes for "value1")
+				0x06,                               // Value length (6 bytes for "value1")
 				0x76, 0x61, 0x6c, 0x75, 0x65, 0x31, // Value ("value1")
+				0x52, 0x71, 0xc3, 0xf1, // Checksum
 			}, content)
 		}
 	})
@@ -200,19 +334,38 @@ func TestFileStore_Set(t *testing.T) {
 		defer fs.Close()
 
 		if assert.NoError(t, fs.Set(context.TODO(), "key1", "value2")) {
-			fs.fd.Seek(0, 0)
-			content, err := io.ReadAll(fs.fd)
+			content, err := os.ReadFile(fs.path)
 			require.NoError(t, err)
+			// Legacy file is migrated to the versioned format
 			assert.Equal(t, []byte{
-				0x04, 0x00, // Key length (4 bytes for "key1")
+				0x43, 0x4c, 0x45, 0x46, // Magic ("CLEF")
+				0x01,                   // Format version
+				0x04,                   // Key length (4 bytes for "key1")
 				0x6b, 0x65, 0x79, 0x31, // Key ("key1")
-				0x06, 0x00, // Value length (6 bytes for "value2")
+				0x06,                               // Value length (6 bytes for "value2")
 				0x76, 0x61, 0x6c, 0x75, 0x65, 0x32, // Value ("value2")
+				0xe8, 0x20, 0xca, 0x68, // Checksum
 			}, content)
 		}
 	})
 }
 
+func TestFileStore_Bytes(t *testing.T) {
+	t.Parallel()
+
+	fs, err := NewFileStore(path.Join(t.TempDir(), "store"))
+	require.NoError(t, err)
+	binary := []byte{0x00, 0xff, 0xfe, '\n', 0x80}
+
+	require.NoError(t, fs.SetBytes(context.TODO(), "bin", binary))
+	v, err := fs.GetBytes(context.TODO(), "bin")
+	require.NoError(t, err)
+	assert.Equal(t, binary, v)
+
+	_, err = fs.GetBytes(context.TODO(), "missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
 func TestFileStore_Delete(t *testing.T) {
 	t.Run("delete existing key", func(t *testing.T) {
 		content := []byte{
@@ -236,14 +389,16 @@ func TestFileStore_Delete(t *testing.T) {
 		defer fs.Close()
 
 		if assert.NoError(t, fs.Delete(context.TODO(), "key1")) {
-			fs.fd.Seek(0, 0)
-			remaining, err := io.ReadAll(fs.fd)
+			remaining, err := os.ReadFile(fs.path)
 			require.NoError(t, err)
 			assert.Equal(t, []byte{
-				0x04, 0x00, // Key length (4 bytes for "key2")
+				0x43, 0x4c, 0x45, 0x46, // Magic ("CLEF")
+				0x01,                   // Format version
+				0x04,                   // Key length (4 bytes for "key2")
 				0x6b, 0x65, 0x79, 0x32, // Key ("key2")
-				0x06, 0x00, // Value length (6 bytes for "value2")
+				0x06,                               // Value length (6 bytes for "value2")
 				0x76, 0x61, 0x6c, 0x75, 0x65, 0x32, // Value ("value2")
+				0x0b, 0x27, 0x45, 0xe6, // Checksum
 			}, remaining)
 		}
 	})
@@ -269,3 +424,78 @@ func TestFileStore_Delete(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestFileStore_List(t *testing.T) {
+	filename := path.Join(t.TempDir(), "testfilestore_list")
+	fs, err := NewFileStore(filename)
+	require.NoError(t, err)
+	defer fs.Close()
+
+	require.NoError(t, writeBinaryMap(fs.path, map[string]string{
+		"github_token": "value1",
+		"GITHUB_TOKEN": "value2",
+		"github_app":   "value3",
+	}))
+
+	t.Run("all keys", func(t *testing.T) {
+		keys, err := fs.List(context.TODO(), "")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"GITHUB_TOKEN", "github_app", "github_token"}, keys)
+		}
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		keys, err := fs.List(context.TODO(), "github")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"github_app", "github_token"}, keys)
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		keys, err := fs.List(context.TODO(), "nokey")
+		if assert.NoError(t, err) {
+			assert.Empty(t, keys)
+		}
+	})
+}
+
+func TestFileStore_Concurrent(t *testing.T) {
+	filename := path.Join(t.TempDir(), "testfilestore_concurrent")
+	const writers, keys = 8, 20
+
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each writer opens its own store, as separate clef invocations would.
+			fs, err := NewFileStore(filename)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer fs.Close()
+			for k := range keys {
+				assert.NoError(t, fs.Set(context.TODO(), fmt.Sprintf("key-%d-%d", w, k), "value"))
+				if k%2 == 1 {
+					assert.NoError(t, fs.Delete(context.TODO(), fmt.Sprintf("key-%d-%d", w, k-1)))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	fs, err := NewFileStore(filename)
+	require.NoError(t, err)
+	got, err := fs.List(context.TODO(), "")
+	require.NoError(t, err)
+	assert.Len(t, got, writers*keys/2)
+	for w := range writers {
+		for k := 1; k < keys; k += 2 {
+			assert.Contains(t, got, fmt.Sprintf("key-%d-%d", w, k))
+		}
+	}
+	// No temporary file left behind
+	entries, err := os.ReadDir(path.Dir(filename))
+	require.NoError(t, err)
+	assert.Len(t, entries, 2) // store and lock
+}
@@ -2,7 +2,12 @@ package backend
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
 
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
@@ -18,6 +23,10 @@ func init() {
 // GCPStoreBuilder implements the Builder interface for GCP Secret Manager.
 type GCPStoreBuilder struct {
 	ProjectID string `toml:"project-id"`
+	// RetainVersions is the number of versions left untouched by Set, the new one included. 0 keeps every version.
+	RetainVersions int `toml:"retain-versions,omitempty"`
+	// DestroyOld destroys the versions beyond RetainVersions instead of disabling them. They can't be recovered.
+	DestroyOld bool `toml:"destroy-old,omitempty"`
 }
 
 // Build returns a new OSStore store.
@@ -25,7 +34,13 @@ func (ob *GCPStoreBuilder) Build(ctx context.Context, name string) (Store, error
 	if ob.ProjectID == "" {
 		return nil, fmt.Errorf("missing project-id")
 	}
-	return NewGCPStore(ctx, ob.ProjectID)
+	if ob.RetainVersions < 0 {
+		return nil, fmt.Errorf("retain-versions must not be negative")
+	}
+	if ob.DestroyOld && ob.RetainVersions == 0 {
+		return nil, fmt.Errorf("destroy-old needs retain-versions")
+	}
+	return NewGCPStore(ctx, ob.ProjectID, ob.RetainVersions, ob.DestroyOld)
 }
 
 type SecretManagerClient interface {
@@ -35,18 +50,24 @@ type SecretManagerClient interface {
 	AddSecretVersion(context.Context, *secretmanagerpb.AddSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
 	ListSecretVersions(context.Context, *secretmanagerpb.ListSecretVersionsRequest, ...gax.CallOption) *secretmanager.SecretVersionIterator
 	DisableSecretVersion(context.Context, *secretmanagerpb.DisableSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
+	EnableSecretVersion(context.Context, *secretmanagerpb.EnableSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
 	DestroySecretVersion(context.Context, *secretmanagerpb.DestroySecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)
 	DeleteSecret(context.Context, *secretmanagerpb.DeleteSecretRequest, ...gax.CallOption) error
+	ListSecrets(context.Context, *secretmanagerpb.ListSecretsRequest, ...gax.CallOption) *secretmanager.SecretIterator
 }
 
 type GCPStore struct {
 	client SecretManagerClient
 
-	projectID string
+	projectID      string
+	retainVersions int
+	destroyOld     bool
 }
 
 // NewGCPStore creates a new GCP Secret Manager Store.
-func NewGCPStore(ctx context.Context, projectID string) (*GCPStore, error) {
+// Set keeps the last retainVersions versions, disabling the older ones, or destroying them if destroyOld is set.
+// A zero retainVersions keeps every version.
+func NewGCPStore(ctx context.Context, projectID string, retainVersions int, destroyOld bool) (*GCPStore, error) {
 	client, err := secretmanager.NewClient(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("setup client: %w", err)
@@ -54,24 +75,58 @@ func NewGCPStore(ctx context.Context, projectID string) (*GCPStore, error) {
 	return &GCPStore{
 		client,
 		projectID,
+		retainVersions,
+		destroyOld,
 	}, nil
 }
 
+// Close closes the Secret Manager client.
+func (o *GCPStore) Close() error {
+	if c, ok := o.client.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
 // Get implements the Store.Get method.
 func (o *GCPStore) Get(ctx context.Context, k string) (string, error) {
+	return o.GetVersion(ctx, k, "latest")
+}
+
+// GetBytes implements the ByteStore.GetBytes method.
+func (o *GCPStore) GetBytes(ctx context.Context, k string) ([]byte, error) {
+	return o.access(ctx, k, "latest")
+}
+
+// GetVersion implements the VersionGetter.GetVersion method.
+// The version is a version number, or the latest alias.
+func (o *GCPStore) GetVersion(ctx context.Context, k, version string) (string, error) {
+	data, err := o.access(ctx, k, version)
+	return string(data), err
+}
+
+func (o *GCPStore) access(ctx context.Context, k, version string) ([]byte, error) {
 	res, err := o.client.AccessSecretVersion(ctx,
 		&secretmanagerpb.AccessSecretVersionRequest{
-			Name: secretLatestVersion(o, k),
+			Name: secretVersion(o, k, version),
 		})
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrKeyNotFound
+	}
 	if err != nil {
-		return "", fmt.Errorf("access gcp secret version: %w", err)
+		return nil, fmt.Errorf("access gcp secret version: %w", err)
 	}
 
-	return string(res.Payload.GetData()), nil
+	return res.Payload.GetData(), nil
 }
 
 // Set implements the Store.Set method
 func (o *GCPStore) Set(ctx context.Context, k, v string) error {
+	return o.SetBytes(ctx, k, []byte(v))
+}
+
+// SetBytes implements the ByteStore.SetBytes method.
+func (o *GCPStore) SetBytes(ctx context.Context, k string, v []byte) error {
 	// Try getting the secret, if it already exists
 	secret, err := o.client.GetSecret(ctx,
 		&secretmanagerpb.GetSecretRequest{
@@ -99,40 +154,129 @@ func (o *GCPStore) Set(ctx context.Context, k, v string) error {
 		}
 	}
 
-	version, err := o.client.AddSecretVersion(ctx,
+	_, err = o.client.AddSecretVersion(ctx,
 		&secretmanagerpb.AddSecretVersionRequest{
 			Parent:  secret.GetName(),
-			Payload: &secretmanagerpb.SecretPayload{Data: []byte(v)},
+			Payload: &secretmanagerpb.SecretPayload{Data: v},
 		})
 	if err != nil {
 		return fmt.Errorf("add secret version: %w", err)
 	}
 
-	// Cleanup old versions
+	if o.retainVersions > 0 {
+		if err := o.retire(ctx, secret.GetName()); err != nil {
+			return fmt.Errorf("cleanup old versions: %w", err)
+		}
+	}
+	return nil
+}
+
+// versions returns the versions of the secret, newest first.
+func (o *GCPStore) versions(ctx context.Context, secret string) ([]*secretmanagerpb.SecretVersion, error) {
+	var versions []*secretmanagerpb.SecretVersion
 	for v, err := range o.client.ListSecretVersions(ctx,
 		&secretmanagerpb.ListSecretVersionsRequest{
-			Parent: secret.GetName(),
+			Parent: secret,
 		}).All() {
 		if err != nil {
+			return nil, fmt.Errorf("list gcp secret versions: %w", err)
+		}
+		versions = append(versions, v)
+	}
+	sortGCPVersions(versions)
+	return versions, nil
+}
+
+func sortGCPVersions(versions []*secretmanagerpb.SecretVersion) {
+	slices.SortStableFunc(versions, func(a, b *secretmanagerpb.SecretVersion) int {
+		return b.GetCreateTime().AsTime().Compare(a.GetCreateTime().AsTime())
+	})
+}
+
+// retiredGCPVersions returns the versions beyond the retain newest ones, destroyed versions excluded.
+// versions are sorted newest first.
+func retiredGCPVersions(versions []*secretmanagerpb.SecretVersion, retain int) []*secretmanagerpb.SecretVersion {
+	versions = slices.DeleteFunc(slices.Clone(versions), func(v *secretmanagerpb.SecretVersion) bool {
+		return v.GetState() == secretmanagerpb.SecretVersion_DESTROYED
+	})
+	return versions[min(retain, len(versions)):]
+}
+
+// retire disables, or destroys, the versions of secret beyond the retained ones.
+func (o *GCPStore) retire(ctx context.Context, secret string) error {
+	versions, err := o.versions(ctx, secret)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, v := range retiredGCPVersions(versions, o.retainVersions) {
+		switch {
+		case o.destroyOld:
+			_, err = o.client.DestroySecretVersion(ctx,
+				&secretmanagerpb.DestroySecretVersionRequest{
+					Name: v.GetName(),
+				})
+		case v.GetState() == secretmanagerpb.SecretVersion_ENABLED:
+			_, err = o.client.DisableSecretVersion(ctx,
+				&secretmanagerpb.DisableSecretVersionRequest{
+					Name: v.GetName(),
+				})
+		default:
 			continue
 		}
-		if v.GetName() != version.GetName() {
-			switch v.State {
-			case secretmanagerpb.SecretVersion_ENABLED:
-				_, _ = o.client.DisableSecretVersion(ctx,
-					&secretmanagerpb.DisableSecretVersionRequest{
-						Name: v.GetName(),
-					})
-			case secretmanagerpb.SecretVersion_DISABLED:
-				_, _ = o.client.DestroySecretVersion(ctx,
-					&secretmanagerpb.DestroySecretVersionRequest{
-						Name: v.GetName(),
-					})
-			}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("version %s: %w", path.Base(v.GetName()), err))
 		}
 	}
+	return errors.Join(errs...)
+}
 
-	return nil
+// History implements the Versioned.History method.
+// The current version is the latest one, labeled with its state.
+func (o *GCPStore) History(ctx context.Context, k string) ([]Version, error) {
+	versions, err := o.versions(ctx, secretName(o, k))
+	if err != nil {
+		return nil, err
+	}
+	return gcpHistory(versions), nil
+}
+
+// gcpHistory returns the history of the versions, sorted newest first.
+func gcpHistory(versions []*secretmanagerpb.SecretVersion) []Version {
+	history := make([]Version, len(versions))
+	for i, v := range versions {
+		history[i] = Version{
+			ID:      path.Base(v.GetName()),
+			Created: v.GetCreateTime().AsTime(),
+			Current: i == 0,
+			Labels:  []string{strings.ToLower(v.GetState().String())},
+		}
+	}
+	return history
+}
+
+// Rollback implements the Versioned.Rollback method.
+// The value of version is added as a new version, the version being enabled first if it is disabled.
+func (o *GCPStore) Rollback(ctx context.Context, k, version string) error {
+	req := &secretmanagerpb.AccessSecretVersionRequest{
+		Name: secretVersion(o, k, version),
+	}
+	res, err := o.client.AccessSecretVersion(ctx, req)
+	if status.Code(err) == codes.FailedPrecondition {
+		// Disabled versions can't be accessed
+		if _, err := o.client.EnableSecretVersion(ctx,
+			&secretmanagerpb.EnableSecretVersionRequest{
+				Name: req.GetName(),
+			}); err != nil {
+			return fmt.Errorf("enable gcp secret version: %w", err)
+		}
+		res, err = o.client.AccessSecretVersion(ctx, req)
+	}
+	if err != nil {
+		return fmt.Errorf("access gcp secret version: %w", err)
+	}
+
+	return o.SetBytes(ctx, k, res.Payload.GetData())
 }
 
 // Delete implements the Store.Delete method.
@@ -143,10 +287,28 @@ func (o *GCPStore) Delete(ctx context.Context, k string) error {
 		})
 }
 
+// List implements the Lister.List method.
+func (o *GCPStore) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	for s, err := range o.client.ListSecrets(ctx,
+		&secretmanagerpb.ListSecretsRequest{
+			Parent: fmt.Sprintf("projects/%s", o.projectID),
+		}).All() {
+		if err != nil {
+			return nil, fmt.Errorf("list gcp secrets: %w", err)
+		}
+		if k := path.Base(s.GetName()); strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
 func secretName(store *GCPStore, k string) string {
 	return fmt.Sprintf("projects/%s/secrets/%s", store.projectID, k)
 }
 
-func secretLatestVersion(store *GCPStore, k string) string {
-	return fmt.Sprintf("%s/versions/latest", secretName(store, k))
+func secretVersion(store *GCPStore, k, version string) string {
+	return fmt.Sprintf("%s/versions/%s", secretName(store, k), version)
 }
@@ -3,12 +3,25 @@ package backend
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
+	"path"
+	"strconv"
 	"testing"
+	"time"
 
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
 	"github.com/stretchr/testify/assert"
 	mock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestGCPStore_Get(t *testing.T) {
@@ -28,6 +41,21 @@ func TestGCPStore_Get(t *testing.T) {
 		assert.ErrorIs(t, err, therr)
 	})
 
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+
+		smc := NewMockSecretManagerClient(t)
+		smc.EXPECT().
+			AccessSecretVersion(mock.Anything, &secretmanagerpb.AccessSecretVersionRequest{Name: "projects/pid/secrets/foo/versions/latest"}).
+			Return(nil, status.Error(codes.NotFound, "Secret [projects/pid/secrets/foo] not found or has no versions."))
+		store := &GCPStore{client: smc, projectID: "pid"}
+
+		_, err := store.Get(context.TODO(), "foo")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+		_, err = store.GetBytes(context.TODO(), "foo")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
 	t.Run("nominal", func(t *testing.T) {
 		t.Parallel()
 
@@ -47,6 +75,51 @@ func TestGCPStore_Get(t *testing.T) {
 	})
 }
 
+func TestGCPStore_Bytes(t *testing.T) {
+	t.Parallel()
+
+	binary := []byte{0x00, 0xff}
+	smc := NewMockSecretManagerClient(t)
+	smc.EXPECT().
+		AccessSecretVersion(mock.Anything, &secretmanagerpb.AccessSecretVersionRequest{Name: "projects/pid/secrets/foo/versions/latest"}).
+		Return(&secretmanagerpb.AccessSecretVersionResponse{
+			Payload: &secretmanagerpb.SecretPayload{Data: binary},
+		}, nil)
+	smc.EXPECT().
+		GetSecret(mock.Anything, mock.Anything).
+		Return(&secretmanagerpb.Secret{Name: "projects/pid/secrets/foo"}, nil)
+	smc.EXPECT().
+		AddSecretVersion(mock.Anything, &secretmanagerpb.AddSecretVersionRequest{
+			Parent:  "projects/pid/secrets/foo",
+			Payload: &secretmanagerpb.SecretPayload{Data: binary},
+		}).
+		Return(&secretmanagerpb.SecretVersion{Name: "projects/pid/secrets/foo/versions/2"}, nil)
+	store := &GCPStore{client: smc, projectID: "pid"}
+
+	value, err := store.GetBytes(context.TODO(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, binary, value)
+	assert.NoError(t, store.SetBytes(context.TODO(), "foo", binary))
+}
+
+func TestGCPStore_GetVersion(t *testing.T) {
+	t.Parallel()
+
+	smc := NewMockSecretManagerClient(t)
+	smc.EXPECT().
+		AccessSecretVersion(mock.Anything, &secretmanagerpb.AccessSecretVersionRequest{Name: "projects/pid/secrets/foo/versions/3"}).
+		Return(&secretmanagerpb.AccessSecretVersionResponse{
+			Payload: &secretmanagerpb.SecretPayload{
+				Data: []byte("old"),
+			},
+		}, nil)
+	store := &GCPStore{client: smc, projectID: "pid"}
+
+	value, err := store.GetVersion(context.TODO(), "foo", "3")
+	assert.NoError(t, err)
+	assert.Equal(t, "old", value)
+}
+
 func TestGCPStore_Set(t *testing.T) {
 	t.Parallel()
 
@@ -65,9 +138,143 @@ func TestGCPStore_Set(t *testing.T) {
 			ListSecretVersions(mock.Anything, mock.Anything).
 			Return(&secretmanager.SecretVersionIterator{})
 
+		store := &GCPStore{client: smc, projectID: "pid", retainVersions: 1}
+		assert.NoError(t, store.Set(context.TODO(), "foo", "bar"))
+
+	})
+
+	t.Run("keep versions", func(t *testing.T) {
+		t.Parallel()
+
+		smc := NewMockSecretManagerClient(t)
+		smc.EXPECT().
+			GetSecret(mock.Anything, &secretmanagerpb.GetSecretRequest{Name: "projects/pid/secrets/foo"}).
+			Return(&secretmanagerpb.Secret{Name: "projects/pid/secrets/foo"}, nil)
+		smc.EXPECT().
+			AddSecretVersion(mock.Anything, &secretmanagerpb.AddSecretVersionRequest{
+				Parent:  "projects/pid/secrets/foo",
+				Payload: &secretmanagerpb.SecretPayload{Data: []byte("bar")},
+			}).
+			Return(&secretmanagerpb.SecretVersion{Name: "projects/pid/secrets/foo/versions/2"}, nil)
+
+		// Versions are not listed, nor disabled
 		store := &GCPStore{client: smc, projectID: "pid"}
 		assert.NoError(t, store.Set(context.TODO(), "foo", "bar"))
+	})
+}
+
+func TestGCPStoreBuilder_Build(t *testing.T) {
+	t.Parallel()
+
+	tcs := map[string]struct {
+		builder GCPStoreBuilder
+		err     string
+	}{
+		"missing project":    {GCPStoreBuilder{}, "missing project-id"},
+		"negative retention": {GCPStoreBuilder{ProjectID: "pid", RetainVersions: -1}, "retain-versions must not be negative"},
+		"destroy everything": {GCPStoreBuilder{ProjectID: "pid", DestroyOld: true}, "destroy-old needs retain-versions"},
+	}
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := tc.builder.Build(context.TODO(), "gcp")
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func testGCPVersions() []*secretmanagerpb.SecretVersion {
+	version := func(n int, state secretmanagerpb.SecretVersion_State) *secretmanagerpb.SecretVersion {
+		return &secretmanagerpb.SecretVersion{
+			Name:       fmt.Sprintf("projects/pid/secrets/foo/versions/%d", n),
+			CreateTime: timestamppb.New(time.Date(2024, 1, n, 0, 0, 0, 0, time.UTC)),
+			State:      state,
+		}
+	}
+	return []*secretmanagerpb.SecretVersion{
+		version(1, secretmanagerpb.SecretVersion_DESTROYED),
+		version(3, secretmanagerpb.SecretVersion_ENABLED),
+		version(2, secretmanagerpb.SecretVersion_DISABLED),
+		version(4, secretmanagerpb.SecretVersion_ENABLED),
+	}
+}
+
+func TestRetiredGCPVersions(t *testing.T) {
+	t.Parallel()
+
+	versions := testGCPVersions()
+	sortGCPVersions(versions)
+
+	names := func(versions []*secretmanagerpb.SecretVersion) []string {
+		var names []string
+		for _, v := range versions {
+			names = append(names, path.Base(v.GetName()))
+		}
+		return names
+	}
+	assert.Equal(t, []string{"3", "2"}, names(retiredGCPVersions(versions, 1)))
+	assert.Equal(t, []string{"2"}, names(retiredGCPVersions(versions, 2)))
+	assert.Empty(t, retiredGCPVersions(versions, 5))
+}
+
+func TestGCPHistory(t *testing.T) {
+	t.Parallel()
+
+	versions := testGCPVersions()
+	sortGCPVersions(versions)
+
+	assert.Equal(t, []Version{
+		{ID: "4", Created: time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC), Current: true, Labels: []string{"enabled"}},
+		{ID: "3", Created: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), Labels: []string{"enabled"}},
+		{ID: "2", Created: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Labels: []string{"disabled"}},
+		{ID: "1", Created: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Labels: []string{"destroyed"}},
+	}, gcpHistory(versions))
+}
+
+func TestGCPStore_Rollback(t *testing.T) {
+	t.Parallel()
+
+	t.Run("disabled version", func(t *testing.T) {
+		t.Parallel()
+
+		access := &secretmanagerpb.AccessSecretVersionRequest{Name: "projects/pid/secrets/foo/versions/2"}
+		smc := NewMockSecretManagerClient(t)
+		smc.EXPECT().
+			AccessSecretVersion(mock.Anything, access).
+			Return(nil, status.Error(codes.FailedPrecondition, "version is disabled")).Once()
+		smc.EXPECT().
+			EnableSecretVersion(mock.Anything, &secretmanagerpb.EnableSecretVersionRequest{Name: "projects/pid/secrets/foo/versions/2"}).
+			Return(&secretmanagerpb.SecretVersion{}, nil).Once()
+		smc.EXPECT().
+			AccessSecretVersion(mock.Anything, access).
+			Return(&secretmanagerpb.AccessSecretVersionResponse{
+				Payload: &secretmanagerpb.SecretPayload{Data: []byte("old")},
+			}, nil).Once()
+		smc.EXPECT().
+			GetSecret(mock.Anything, mock.Anything).
+			Return(&secretmanagerpb.Secret{Name: "projects/pid/secrets/foo"}, nil)
+		smc.EXPECT().
+			AddSecretVersion(mock.Anything, &secretmanagerpb.AddSecretVersionRequest{
+				Parent:  "projects/pid/secrets/foo",
+				Payload: &secretmanagerpb.SecretPayload{Data: []byte("old")},
+			}).
+			Return(&secretmanagerpb.SecretVersion{Name: "projects/pid/secrets/foo/versions/5"}, nil)
+		store := &GCPStore{client: smc, projectID: "pid"}
 
+		assert.NoError(t, store.Rollback(context.TODO(), "foo", "2"))
+	})
+
+	t.Run("access error", func(t *testing.T) {
+		t.Parallel()
+
+		smc := NewMockSecretManagerClient(t)
+		smc.EXPECT().
+			AccessSecretVersion(mock.Anything, mock.Anything).
+			Return(nil, status.Error(codes.NotFound, "no such version"))
+		store := &GCPStore{client: smc, projectID: "pid"}
+
+		assert.ErrorContains(t, store.Rollback(context.TODO(), "foo", "9"), "access gcp secret version")
 	})
 }
 
@@ -98,3 +305,60 @@ func TestGCPStore_Delete(t *testing.T) {
 		assert.NoError(t, store.Delete(context.TODO(), "foo"))
 	})
 }
+
+// fakeSecretManager serves secrets over an in-memory gRPC connection,
+// for the iterator based calls the mock client can't fake.
+type fakeSecretManager struct {
+	secretmanagerpb.UnimplementedSecretManagerServiceServer
+	secrets []string
+}
+
+// ListSecrets returns two secrets per page, to go through pagination.
+func (f *fakeSecretManager) ListSecrets(_ context.Context, req *secretmanagerpb.ListSecretsRequest) (*secretmanagerpb.ListSecretsResponse, error) {
+	start, _ := strconv.Atoi(req.GetPageToken())
+	end := min(start+2, len(f.secrets))
+	res := &secretmanagerpb.ListSecretsResponse{}
+	for _, s := range f.secrets[start:end] {
+		res.Secrets = append(res.Secrets, &secretmanagerpb.Secret{Name: req.GetParent() + "/secrets/" + s})
+	}
+	if end < len(f.secrets) {
+		res.NextPageToken = strconv.Itoa(end)
+	}
+	return res, nil
+}
+
+// newFakeSecretManagerClient returns a real Secret Manager client talking to fake.
+func newFakeSecretManagerClient(t *testing.T, fake *fakeSecretManager) *secretmanager.Client {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	secretmanagerpb.RegisterSecretManagerServiceServer(srv, fake)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	client, err := secretmanager.NewClient(context.TODO(), option.WithGRPCConn(conn))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestGCPStore_List(t *testing.T) {
+	t.Parallel()
+
+	client := newFakeSecretManagerClient(t, &fakeSecretManager{
+		secrets: []string{"db-password", "api-key", "db-user", "other", "db-host"},
+	})
+	store := &GCPStore{client: client, projectID: "pid"}
+
+	keys, err := store.List(context.TODO(), "db-")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db-host", "db-password", "db-user"}, keys)
+
+	keys, err = store.List(context.TODO(), "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api-key", "db-host", "db-password", "db-user", "other"}, keys)
+}
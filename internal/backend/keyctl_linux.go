@@ -0,0 +1,197 @@
+//go:build linux
+
+package backend
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// keyctlPerm grants every permission to the possessor and the owner of a key.
+const keyctlPerm = 0x3f3f0000
+
+func init() {
+	registerBuilder("keyctl", func() Builder { return new(KeyctlStoreBuilder) })
+}
+
+// KeyctlStoreBuilder implements the Builder interface for KeyctlStore.
+type KeyctlStoreBuilder struct {
+	// Keyring is one of user (default), session or persistent.
+	Keyring string `toml:"keyring,omitempty"`
+	// Namespace defaults to the store name.
+	Namespace string `toml:"namespace,omitempty"`
+	// Timeout expires keys after they are set. Keys never expire when zero.
+	Timeout time.Duration `toml:"timeout,omitempty"`
+}
+
+// Build returns a new KeyctlStore store.
+func (kb *KeyctlStoreBuilder) Build(ctx context.Context, name string) (Store, error) {
+	var ring int
+	switch kb.Keyring {
+	case "", "user":
+		ring = unix.KEY_SPEC_USER_KEYRING
+	case "session":
+		ring = unix.KEY_SPEC_SESSION_KEYRING
+	case "persistent":
+		// The persistent keyring of the current user, linked to the session keyring to be possessed
+		id, err := unix.KeyctlInt(unix.KEYCTL_GET_PERSISTENT, -1, unix.KEY_SPEC_SESSION_KEYRING, 0, 0)
+		if err != nil {
+			return nil, fmt.Errorf("get persistent keyring: %w", err)
+		}
+		ring = id
+	default:
+		return nil, fmt.Errorf("unsupported keyring '%s'", kb.Keyring)
+	}
+	return NewKeyctlStore(ring, firstNonEmpty(kb.Namespace, name), kb.Timeout), nil
+}
+
+// KeyctlStore uses the Linux kernel key retention service to store secrets.
+// Secrets are "user" keys linked to the keyring, described as clef:<namespace>:<key>.
+// They only live in memory, and optionally expire after a timeout.
+//
+// Keys are readable by every process of the user, like the user keyring itself.
+// Otherwise, reading them would require to possess them, which depends on the session keyring setup.
+type KeyctlStore struct {
+	ring    int
+	prefix  string
+	timeout time.Duration
+}
+
+// NewKeyctlStore creates a new KeyctlStore in the keyring with ID ring, which may be a special keyring ID.
+func NewKeyctlStore(ring int, namespace string, timeout time.Duration) *KeyctlStore {
+	return &KeyctlStore{ring, "clef:" + namespace + ":", timeout}
+}
+
+// keys returns the IDs of the store keys linked to the keyring, by key.
+// Expired, revoked and unviewable keys are skipped.
+//
+// The keyring is scanned rather than searched, so that nested keyrings are ignored, as they are on write.
+func (ks *KeyctlStore) keys() (map[string]int, error) {
+	payload, err := keyctlRead(ks.ring)
+	if err != nil {
+		return nil, fmt.Errorf("read keyring: %w", err)
+	}
+
+	keys := make(map[string]int)
+	// The keyring payload is an array of key IDs
+	for i := 0; i+4 <= len(payload); i += 4 {
+		id := int(int32(binary.NativeEndian.Uint32(payload[i:])))
+		desc, err := unix.KeyctlString(unix.KEYCTL_DESCRIBE, id)
+		if err != nil {
+			continue
+		}
+		// type;uid;gid;perm;description
+		fields := strings.SplitN(desc, ";", 5)
+		if len(fields) != 5 || fields[0] != "user" {
+			continue
+		}
+		if k, ok := strings.CutPrefix(fields[4], ks.prefix); ok {
+			keys[k] = id
+		}
+	}
+	return keys, nil
+}
+
+// search returns the ID of the key k, or ErrKeyNotFound.
+func (ks *KeyctlStore) search(k string) (int, error) {
+	keys, err := ks.keys()
+	if err != nil {
+		return 0, err
+	}
+	id, ok := keys[k]
+	if !ok {
+		return 0, ErrKeyNotFound
+	}
+	return id, nil
+}
+
+// keyctlRead returns the payload of the key or keyring id.
+func keyctlRead(id int) ([]byte, error) {
+	size, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, nil, 0)
+	for err == nil {
+		buf := make([]byte, size)
+		var n int
+		n, err = unix.KeyctlBuffer(unix.KEYCTL_READ, id, buf, 0)
+		if err == nil && n <= size {
+			return buf[:n], nil
+		}
+		// The payload grew in between, try again
+		size = n
+	}
+	return nil, err
+}
+
+// Get implements the Store.Get method.
+func (ks *KeyctlStore) Get(ctx context.Context, k string) (string, error) {
+	id, err := ks.search(k)
+	if err != nil {
+		return "", err
+	}
+	v, err := keyctlRead(id)
+	if errors.Is(err, unix.EKEYEXPIRED) || errors.Is(err, unix.EKEYREVOKED) {
+		// Expired since the keyring scan
+		return "", ErrKeyNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("read key: %w", err)
+	}
+	return string(v), nil
+}
+
+// Set implements the Store.Set method.
+// An existing key is updated in place, and its timeout reset.
+func (ks *KeyctlStore) Set(ctx context.Context, k, v string) error {
+	id, err := unix.AddKey("user", ks.prefix+k, []byte(v), ks.ring)
+	if err != nil {
+		return fmt.Errorf("add key: %w", err)
+	}
+	if _, err := unix.KeyctlInt(unix.KEYCTL_SETPERM, id, keyctlPerm, 0, 0); err != nil {
+		return fmt.Errorf("set key permissions: %w", err)
+	}
+	if ks.timeout > 0 {
+		// Round up to the second, so that sub-second timeouts still expire
+		secs := int((ks.timeout + time.Second - 1) / time.Second)
+		if _, err := unix.KeyctlInt(unix.KEYCTL_SET_TIMEOUT, id, secs, 0, 0); err != nil {
+			return fmt.Errorf("set key timeout: %w", err)
+		}
+	}
+	return nil
+}
+
+// Delete implements the Store.Delete method.
+func (ks *KeyctlStore) Delete(ctx context.Context, k string) error {
+	id, err := ks.search(k)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := unix.KeyctlInt(unix.KEYCTL_INVALIDATE, id, 0, 0, 0); err != nil {
+		return fmt.Errorf("invalidate key: %w", err)
+	}
+	return nil
+}
+
+// List implements the Lister.List method.
+func (ks *KeyctlStore) List(ctx context.Context, prefix string) ([]string, error) {
+	all, err := ks.keys()
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for k := range all {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
@@ -0,0 +1,107 @@
+//go:build linux
+
+package backend
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// newTestKeyring creates a keyring linked to the user keyring, removed at the end of the test.
+func newTestKeyring(t *testing.T) int {
+	t.Helper()
+	ring, err := unix.AddKey("keyring", fmt.Sprintf("clef-test-%d-%s", os.Getpid(), t.Name()), nil, unix.KEY_SPEC_USER_KEYRING)
+	if err != nil {
+		t.Skipf("kernel keyring unavailable: %v", err)
+	}
+	_, err = unix.KeyctlInt(unix.KEYCTL_SETPERM, ring, keyctlPerm, 0, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		unix.KeyctlInt(unix.KEYCTL_INVALIDATE, ring, 0, 0, 0)
+	})
+	return ring
+}
+
+func TestKeyctlStoreBuilder_Build(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		s, err := (&KeyctlStoreBuilder{}).Build(context.TODO(), "test")
+		if assert.NoError(t, err) {
+			assert.Equal(t, &KeyctlStore{unix.KEY_SPEC_USER_KEYRING, "clef:test:", 0}, s)
+		}
+	})
+
+	t.Run("session", func(t *testing.T) {
+		s, err := (&KeyctlStoreBuilder{Keyring: "session", Namespace: "ns", Timeout: time.Hour}).Build(context.TODO(), "test")
+		if assert.NoError(t, err) {
+			assert.Equal(t, &KeyctlStore{unix.KEY_SPEC_SESSION_KEYRING, "clef:ns:", time.Hour}, s)
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := (&KeyctlStoreBuilder{Keyring: "thread"}).Build(context.TODO(), "test")
+		assert.EqualError(t, err, "unsupported keyring 'thread'")
+	})
+}
+
+func TestKeyctlStore(t *testing.T) {
+	store := NewKeyctlStore(newTestKeyring(t), "test", 0)
+	other := NewKeyctlStore(store.ring, "other", 0)
+	ctx := context.TODO()
+
+	t.Run("get not found", func(t *testing.T) {
+		_, err := store.Get(ctx, "nokey")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("set and get", func(t *testing.T) {
+		require.NoError(t, store.Set(ctx, "db", "hunter2"))
+		require.NoError(t, store.Set(ctx, "db", "hunter3"))
+		v, err := store.Get(ctx, "db")
+		require.NoError(t, err)
+		assert.Equal(t, "hunter3", v)
+
+		_, err = other.Get(ctx, "db")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		require.NoError(t, store.Set(ctx, "api", "x"))
+		require.NoError(t, other.Set(ctx, "foo", "bar"))
+
+		keys, err := store.List(ctx, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"api", "db"}, keys)
+
+		keys, err = store.List(ctx, "d")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"db"}, keys)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, "api"))
+		require.NoError(t, store.Delete(ctx, "api"))
+		_, err := store.Get(ctx, "api")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+}
+
+func TestKeyctlStore_Timeout(t *testing.T) {
+	store := NewKeyctlStore(newTestKeyring(t), "test", 500*time.Millisecond)
+	ctx := context.TODO()
+
+	require.NoError(t, store.Set(ctx, "temp", "value"))
+	v, err := store.Get(ctx, "temp")
+	require.NoError(t, err)
+	assert.Equal(t, "value", v)
+
+	time.Sleep(1500 * time.Millisecond)
+	_, err = store.Get(ctx, "temp")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
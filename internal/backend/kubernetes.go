@@ -0,0 +1,212 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/retry"
+)
+
+func init() {
+	registerBuilder("kubernetes", func() Builder { return new(KubernetesStoreBuilder) })
+}
+
+// KubernetesStoreBuilder implements the Builder interface for Kubernetes Secrets.
+type KubernetesStoreBuilder struct {
+	// Kubeconfig is the kubeconfig file, defaults to KUBECONFIG or ~/.kube/config.
+	Kubeconfig string `toml:"kubeconfig,omitempty"`
+	// Context is the kubeconfig context to use, defaults to the current context.
+	Context string `toml:"context,omitempty"`
+	// Namespace defaults to the context namespace.
+	Namespace string `toml:"namespace,omitempty"`
+	// Secret is the name of the Secret holding every key.
+	// If empty, each key is its own Secret, and its value is held in Field.
+	Secret string `toml:"secret,omitempty"`
+	// Field is the Secret data key holding the value when there is one Secret per key, defaults to "value".
+	Field string `toml:"field,omitempty"`
+}
+
+// Build returns a new KubernetesStore store.
+func (kb *KubernetesStoreBuilder) Build(ctx context.Context, name string) (Store, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kb.Kubeconfig
+	cc := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{CurrentContext: kb.Context})
+
+	namespace := kb.Namespace
+	if namespace == "" {
+		ns, _, err := cc.Namespace()
+		if err != nil {
+			return nil, fmt.Errorf("load kubeconfig: %w", err)
+		}
+		namespace = ns
+	}
+	config, err := cc.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load kubeconfig: %w", err)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("setup client: %w", err)
+	}
+
+	return NewKubernetesStore(clientset.CoreV1().Secrets(namespace), kb.Secret, kb.Field), nil
+}
+
+// KubernetesStore uses Kubernetes Secrets of a namespace to store secrets.
+//
+// Keys are either the data keys of a single Secret,
+// or the names of Secrets holding their value in a single data key.
+type KubernetesStore struct {
+	client typedcorev1.SecretInterface
+	secret string
+	field  string
+}
+
+// NewKubernetesStore creates a new KubernetesStore.
+// When secret is empty, there is one Secret per key, with its value held in field ("value" if empty).
+func NewKubernetesStore(client typedcorev1.SecretInterface, secret, field string) *KubernetesStore {
+	return &KubernetesStore{client, secret, firstNonEmpty(field, "value")}
+}
+
+// locate returns the Secret name and data key holding k.
+func (ks *KubernetesStore) locate(k string) (string, string) {
+	if ks.secret != "" {
+		return ks.secret, k
+	}
+	return k, ks.field
+}
+
+// Get implements the Store.Get method.
+func (ks *KubernetesStore) Get(ctx context.Context, k string) (string, error) {
+	name, field := ks.locate(k)
+	secret, err := ks.client.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", ErrKeyNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("get kubernetes secret: %w", err)
+	}
+	v, ok := secret.Data[field]
+	if !ok {
+		return "", ErrKeyNotFound
+	}
+	return string(v), nil
+}
+
+// Set implements the Store.Set method.
+// Missing Secrets are created, other data keys of existing ones are preserved.
+func (ks *KubernetesStore) Set(ctx context.Context, k, v string) error {
+	name, field := ks.locate(k)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := ks.client.Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Type:       corev1.SecretTypeOpaque,
+				Data:       map[string][]byte{field: []byte(v)},
+			}
+			_, err = ks.client.Create(ctx, secret, metav1.CreateOptions{})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte)
+		}
+		secret.Data[field] = []byte(v)
+		_, err = ks.client.Update(ctx, secret, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("set kubernetes secret: %w", err)
+	}
+	return nil
+}
+
+// GetBytes implements the ByteStore.GetBytes method.
+func (ks *KubernetesStore) GetBytes(ctx context.Context, k string) ([]byte, error) {
+	v, err := ks.Get(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(v), nil
+}
+
+// SetBytes implements the ByteStore.SetBytes method.
+func (ks *KubernetesStore) SetBytes(ctx context.Context, k string, v []byte) error {
+	return ks.Set(ctx, k, string(v))
+}
+
+// Delete implements the Store.Delete method.
+// With one Secret per key, the Secret itself is deleted once it holds no more data.
+func (ks *KubernetesStore) Delete(ctx context.Context, k string) error {
+	name, field := ks.locate(k)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := ks.client.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if _, ok := secret.Data[field]; !ok {
+			return nil
+		}
+		delete(secret.Data, field)
+		if ks.secret == "" && len(secret.Data) == 0 {
+			return ks.client.Delete(ctx, name, metav1.DeleteOptions{
+				Preconditions: &metav1.Preconditions{ResourceVersion: &secret.ResourceVersion},
+			})
+		}
+		_, err = ks.client.Update(ctx, secret, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("delete kubernetes secret: %w", err)
+	}
+	return nil
+}
+
+// List implements the Lister.List method.
+func (ks *KubernetesStore) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	if ks.secret != "" {
+		secret, err := ks.client.Get(ctx, ks.secret, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get kubernetes secret: %w", err)
+		}
+		for k := range secret.Data {
+			if strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+	} else {
+		opts := metav1.ListOptions{}
+		for {
+			secrets, err := ks.client.List(ctx, opts)
+			if err != nil {
+				return nil, fmt.Errorf("list kubernetes secrets: %w", err)
+			}
+			for _, s := range secrets.Items {
+				if _, ok := s.Data[ks.field]; ok && strings.HasPrefix(s.Name, prefix) {
+					keys = append(keys, s.Name)
+				}
+			}
+			if secrets.Continue == "" {
+				break
+			}
+			opts.Continue = secrets.Continue
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
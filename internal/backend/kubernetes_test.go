@@ -0,0 +1,159 @@
+package backend
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    namespace: team
+- name: other
+  context:
+    cluster: dev
+current-context: other
+`
+
+func TestKubernetesStoreBuilder_Build(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(kubeconfig, []byte(testKubeconfig), 0600))
+
+	t.Run("context namespace", func(t *testing.T) {
+		builder := &KubernetesStoreBuilder{Kubeconfig: kubeconfig, Context: "dev"}
+		s, err := builder.Build(context.TODO(), "test")
+		if assert.NoError(t, err) {
+			ks := s.(*KubernetesStore)
+			assert.Equal(t, "value", ks.field)
+			assert.Empty(t, ks.secret)
+		}
+	})
+
+	t.Run("unknown context", func(t *testing.T) {
+		builder := &KubernetesStoreBuilder{Kubeconfig: kubeconfig, Context: "prod"}
+		_, err := builder.Build(context.TODO(), "test")
+		assert.ErrorContains(t, err, "load kubeconfig")
+	})
+}
+
+func newTestSecret(name string, data map[string]string) *corev1.Secret {
+	s := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team"}, Data: map[string][]byte{}}
+	for k, v := range data {
+		s.Data[k] = []byte(v)
+	}
+	return s
+}
+
+func TestKubernetesStore_SingleSecret(t *testing.T) {
+	clientset := fake.NewClientset(newTestSecret("app", map[string]string{"db-password": "hunter2"}))
+	secrets := clientset.CoreV1().Secrets("team")
+	store := NewKubernetesStore(secrets, "app", "")
+	ctx := context.TODO()
+
+	t.Run("get", func(t *testing.T) {
+		v, err := store.Get(ctx, "db-password")
+		require.NoError(t, err)
+		assert.Equal(t, "hunter2", v)
+
+		_, err = store.Get(ctx, "nokey")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("set", func(t *testing.T) {
+		require.NoError(t, store.Set(ctx, "api-token", "abc"))
+		s, err := secrets.Get(ctx, "app", metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{"db-password": []byte("hunter2"), "api-token": []byte("abc")}, s.Data)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		keys, err := store.List(ctx, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"api-token", "db-password"}, keys)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, "api-token"))
+		require.NoError(t, store.Delete(ctx, "db-password"))
+		require.NoError(t, store.Delete(ctx, "nokey"))
+		// The configured Secret is kept, even empty
+		s, err := secrets.Get(ctx, "app", metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, s.Data)
+	})
+
+	t.Run("create missing secret", func(t *testing.T) {
+		other := NewKubernetesStore(secrets, "new", "")
+		require.NoError(t, other.Set(ctx, "foo", "bar"))
+		s, err := secrets.Get(ctx, "new", metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, corev1.SecretTypeOpaque, s.Type)
+		assert.Equal(t, map[string][]byte{"foo": []byte("bar")}, s.Data)
+	})
+}
+
+func TestKubernetesStore_SecretPerKey(t *testing.T) {
+	clientset := fake.NewClientset(
+		newTestSecret("db-password", map[string]string{"password": "hunter2", "username": "admin"}),
+		newTestSecret("tls", map[string]string{"tls.crt": "cert"}),
+	)
+	secrets := clientset.CoreV1().Secrets("team")
+	store := NewKubernetesStore(secrets, "", "password")
+	ctx := context.TODO()
+
+	t.Run("get", func(t *testing.T) {
+		v, err := store.Get(ctx, "db-password")
+		require.NoError(t, err)
+		assert.Equal(t, "hunter2", v)
+
+		_, err = store.Get(ctx, "tls")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+		_, err = store.Get(ctx, "nokey")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("set", func(t *testing.T) {
+		require.NoError(t, store.Set(ctx, "api-password", "abc"))
+		v, err := store.Get(ctx, "api-password")
+		require.NoError(t, err)
+		assert.Equal(t, "abc", v)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		keys, err := store.List(ctx, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"api-password", "db-password"}, keys)
+
+		keys, err = store.List(ctx, "db")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"db-password"}, keys)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, "api-password"))
+		_, err := secrets.Get(ctx, "api-password", metav1.GetOptions{})
+		assert.Error(t, err, "empty secret is deleted")
+
+		require.NoError(t, store.Delete(ctx, "db-password"))
+		s, err := secrets.Get(ctx, "db-password", metav1.GetOptions{})
+		require.NoError(t, err, "secret holding other data is kept")
+		assert.Equal(t, map[string][]byte{"username": []byte("admin")}, s.Data)
+
+		require.NoError(t, store.Delete(ctx, "nokey"))
+	})
+}
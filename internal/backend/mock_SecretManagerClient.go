@@ -455,6 +455,77 @@ func (_c *MockSecretManagerClient_DisableSecretVersion_Call) RunAndReturn(run fu
 	return _c
 }
 
+// EnableSecretVersion provides a mock function for the type MockSecretManagerClient
+func (_mock *MockSecretManagerClient) EnableSecretVersion(context1 context.Context, enableSecretVersionRequest *secretmanagerpb.EnableSecretVersionRequest, callOptions ...gax.CallOption) (*secretmanagerpb.SecretVersion, error) {
+	var tmpRet mock.Arguments
+	if len(callOptions) > 0 {
+		tmpRet = _mock.Called(context1, enableSecretVersionRequest, callOptions)
+	} else {
+		tmpRet = _mock.Called(context1, enableSecretVersionRequest)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for EnableSecretVersion")
+	}
+
+	var r0 *secretmanagerpb.SecretVersion
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *secretmanagerpb.EnableSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)); ok {
+		return returnFunc(context1, enableSecretVersionRequest, callOptions...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *secretmanagerpb.EnableSecretVersionRequest, ...gax.CallOption) *secretmanagerpb.SecretVersion); ok {
+		r0 = returnFunc(context1, enableSecretVersionRequest, callOptions...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*secretmanagerpb.SecretVersion)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *secretmanagerpb.EnableSecretVersionRequest, ...gax.CallOption) error); ok {
+		r1 = returnFunc(context1, enableSecretVersionRequest, callOptions...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSecretManagerClient_EnableSecretVersion_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EnableSecretVersion'
+type MockSecretManagerClient_EnableSecretVersion_Call struct {
+	*mock.Call
+}
+
+// EnableSecretVersion is a helper method to define mock.On call
+//   - context1
+//   - enableSecretVersionRequest
+//   - callOptions
+func (_e *MockSecretManagerClient_Expecter) EnableSecretVersion(context1 interface{}, enableSecretVersionRequest interface{}, callOptions ...interface{}) *MockSecretManagerClient_EnableSecretVersion_Call {
+	return &MockSecretManagerClient_EnableSecretVersion_Call{Call: _e.mock.On("EnableSecretVersion",
+		append([]interface{}{context1, enableSecretVersionRequest}, callOptions...)...)}
+}
+
+func (_c *MockSecretManagerClient_EnableSecretVersion_Call) Run(run func(context1 context.Context, enableSecretVersionRequest *secretmanagerpb.EnableSecretVersionRequest, callOptions ...gax.CallOption)) *MockSecretManagerClient_EnableSecretVersion_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]gax.CallOption, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(gax.CallOption)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*secretmanagerpb.EnableSecretVersionRequest), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSecretManagerClient_EnableSecretVersion_Call) Return(secretVersion *secretmanagerpb.SecretVersion, err error) *MockSecretManagerClient_EnableSecretVersion_Call {
+	_c.Call.Return(secretVersion, err)
+	return _c
+}
+
+func (_c *MockSecretManagerClient_EnableSecretVersion_Call) RunAndReturn(run func(context1 context.Context, enableSecretVersionRequest *secretmanagerpb.EnableSecretVersionRequest, callOptions ...gax.CallOption) (*secretmanagerpb.SecretVersion, error)) *MockSecretManagerClient_EnableSecretVersion_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetSecret provides a mock function for the type MockSecretManagerClient
 func (_mock *MockSecretManagerClient) GetSecret(context1 context.Context, getSecretRequest *secretmanagerpb.GetSecretRequest, callOptions ...gax.CallOption) (*secretmanagerpb.Secret, error) {
 	var tmpRet mock.Arguments
@@ -587,3 +658,65 @@ func (_c *MockSecretManagerClient_ListSecretVersions_Call) RunAndReturn(run func
 	_c.Call.Return(run)
 	return _c
 }
+
+// ListSecrets provides a mock function for the type MockSecretManagerClient
+func (_mock *MockSecretManagerClient) ListSecrets(context1 context.Context, listSecretsRequest *secretmanagerpb.ListSecretsRequest, callOptions ...gax.CallOption) *secretmanager.SecretIterator {
+	var tmpRet mock.Arguments
+	if len(callOptions) > 0 {
+		tmpRet = _mock.Called(context1, listSecretsRequest, callOptions)
+	} else {
+		tmpRet = _mock.Called(context1, listSecretsRequest)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListSecrets")
+	}
+
+	var r0 *secretmanager.SecretIterator
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *secretmanagerpb.ListSecretsRequest, ...gax.CallOption) *secretmanager.SecretIterator); ok {
+		r0 = returnFunc(context1, listSecretsRequest, callOptions...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*secretmanager.SecretIterator)
+		}
+	}
+	return r0
+}
+
+// MockSecretManagerClient_ListSecrets_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListSecrets'
+type MockSecretManagerClient_ListSecrets_Call struct {
+	*mock.Call
+}
+
+// ListSecrets is a helper method to define mock.On call
+//   - context1
+//   - listSecretsRequest
+//   - callOptions
+func (_e *MockSecretManagerClient_Expecter) ListSecrets(context1 interface{}, listSecretsRequest interface{}, callOptions ...interface{}) *MockSecretManagerClient_ListSecrets_Call {
+	return &MockSecretManagerClient_ListSecrets_Call{Call: _e.mock.On("ListSecrets",
+		append([]interface{}{context1, listSecretsRequest}, callOptions...)...)}
+}
+
+func (_c *MockSecretManagerClient_ListSecrets_Call) Run(run func(context1 context.Context, listSecretsRequest *secretmanagerpb.ListSecretsRequest, callOptions ...gax.CallOption)) *MockSecretManagerClient_ListSecrets_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]gax.CallOption, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(gax.CallOption)
+			}
+		}
+		run(args[0].(context.Context), args[1].(*secretmanagerpb.ListSecretsRequest), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockSecretManagerClient_ListSecrets_Call) Return(secretIterator *secretmanager.SecretIterator) *MockSecretManagerClient_ListSecrets_Call {
+	_c.Call.Return(secretIterator)
+	return _c
+}
+
+func (_c *MockSecretManagerClient_ListSecrets_Call) RunAndReturn(run func(context1 context.Context, listSecretsRequest *secretmanagerpb.ListSecretsRequest, callOptions ...gax.CallOption) *secretmanager.SecretIterator) *MockSecretManagerClient_ListSecrets_Call {
+	_c.Call.Return(run)
+	return _c
+}
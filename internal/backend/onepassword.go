@@ -0,0 +1,411 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+func init() {
+	registerBuilder("onepassword", func() Builder { return new(OnePasswordStoreBuilder) })
+}
+
+var errOPNotFound = errors.New("1password resource not found")
+
+// OnePasswordStoreBuilder implements the Builder interface for 1Password.
+//
+// Items are read and written through a 1Password Connect server when one is configured,
+// or through the op CLI otherwise.
+type OnePasswordStoreBuilder struct {
+	// ConnectHost is the Connect server URL, defaults to OP_CONNECT_HOST.
+	// The Connect token is read from OP_CONNECT_TOKEN.
+	ConnectHost string `toml:"connect-host,omitempty"`
+	// Account selects the op CLI account, when signed in to several.
+	Account string `toml:"account,omitempty"`
+}
+
+// Build returns a new OnePasswordStore store.
+func (ob *OnePasswordStoreBuilder) Build(ctx context.Context, name string) (Store, error) {
+	host := strings.TrimSuffix(firstNonEmpty(ob.ConnectHost, os.Getenv("OP_CONNECT_HOST")), "/")
+	if host == "" {
+		return &OnePasswordStore{&opCLI{bin: "op", account: ob.Account}}, nil
+	}
+
+	token := os.Getenv("OP_CONNECT_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("missing connect token: set OP_CONNECT_TOKEN")
+	}
+	return &OnePasswordStore{&opConnect{http: http.DefaultClient, host: host, token: token}}, nil
+}
+
+// opItem is a 1Password item, as returned by both Connect and `op item get --format json`.
+type opItem struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Vault struct {
+		ID string `json:"id"`
+	} `json:"vault"`
+	Fields []opField `json:"fields"`
+
+	// raw is the item as read by the op CLI, with the attributes clef doesn't know of
+	raw []byte
+}
+
+type opField struct {
+	ID    string `json:"id,omitempty"`
+	Label string `json:"label"`
+	Type  string `json:"type"`
+	Value string `json:"value,omitempty"`
+}
+
+// field returns the item field with the given label or ID, or nil.
+func (it *opItem) field(name string) *opField {
+	for i, f := range it.Fields {
+		if f.Label == name || f.ID == name {
+			return &it.Fields[i]
+		}
+	}
+	return nil
+}
+
+// opClient abstracts the 1Password access mode.
+// Methods return an error wrapping ErrKeyNotFound when the vault, item or field does not exist.
+type opClient interface {
+	read(ctx context.Context, vault, item, field string) (string, error)
+	item(ctx context.Context, vault, item string) (*opItem, error)
+	createItem(ctx context.Context, vault, item, field, value string) error
+	setField(ctx context.Context, vault string, item *opItem, field, value string) error
+	deleteField(ctx context.Context, vault string, item *opItem, field *opField) error
+}
+
+// OnePasswordStore uses 1Password to store secrets.
+// Keys address a field of an item, as vault/item/field.
+//
+// Set creates the item if needed, as an API credential holding a single concealed field.
+// Delete removes the field, but never the item itself.
+type OnePasswordStore struct {
+	client opClient
+}
+
+func splitOPKey(k string) (vault, item, field string, err error) {
+	parts := strings.Split(k, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("invalid key %s: expected vault/item/field", k)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+// Get implements the Store.Get method.
+func (o *OnePasswordStore) Get(ctx context.Context, k string) (string, error) {
+	vault, item, field, err := splitOPKey(k)
+	if err != nil {
+		return "", err
+	}
+	return o.client.read(ctx, vault, item, field)
+}
+
+// Set implements the Store.Set method.
+func (o *OnePasswordStore) Set(ctx context.Context, k, v string) error {
+	vault, item, field, err := splitOPKey(k)
+	if err != nil {
+		return err
+	}
+	it, err := o.client.item(ctx, vault, item)
+	if errors.Is(err, ErrKeyNotFound) {
+		return o.client.createItem(ctx, vault, item, field, v)
+	}
+	if err != nil {
+		return err
+	}
+	return o.client.setField(ctx, vault, it, field, v)
+}
+
+// Delete implements the Store.Delete method.
+func (o *OnePasswordStore) Delete(ctx context.Context, k string) error {
+	vault, item, field, err := splitOPKey(k)
+	if err != nil {
+		return err
+	}
+	it, err := o.client.item(ctx, vault, item)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	f := it.field(field)
+	if f == nil {
+		return nil
+	}
+	return o.client.deleteField(ctx, vault, it, f)
+}
+
+// opConnect is a minimal client of the 1Password Connect API.
+type opConnect struct {
+	http  *http.Client
+	host  string
+	token string
+}
+
+// do sends a request to the API path p, with in JSON encoded as body, and decodes the response into out.
+// It returns errOPNotFound on 404 responses.
+func (c *opConnect) do(ctx context.Context, method, p string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.host+"/v1/"+p, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return errOPNotFound
+	}
+	if res.StatusCode >= 400 {
+		var cerr struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(res.Body).Decode(&cerr)
+		return fmt.Errorf("%s %s: %s: %s", method, p, res.Status, cerr.Message)
+	}
+	if out == nil || res.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// find returns the ID of the single resource at p whose attr equals value.
+func (c *opConnect) find(ctx context.Context, p, attr, value string) (string, error) {
+	var res []struct {
+		ID string `json:"id"`
+	}
+	q := url.Values{"filter": {fmt.Sprintf("%s eq %q", attr, value)}}
+	if err := c.do(ctx, http.MethodGet, p+"?"+q.Encode(), nil, &res); err != nil && !errors.Is(err, errOPNotFound) {
+		return "", err
+	}
+	switch len(res) {
+	case 0:
+		return "", fmt.Errorf("%s: %w", value, ErrKeyNotFound)
+	case 1:
+		return res[0].ID, nil
+	default:
+		return "", fmt.Errorf("%s is ambiguous: %d matches", value, len(res))
+	}
+}
+
+func (c *opConnect) vaultID(ctx context.Context, vault string) (string, error) {
+	return c.find(ctx, "vaults", "name", vault)
+}
+
+func (c *opConnect) item(ctx context.Context, vault, item string) (*opItem, error) {
+	vid, err := c.vaultID(ctx, vault)
+	if err != nil {
+		return nil, err
+	}
+	iid, err := c.find(ctx, "vaults/"+vid+"/items", "title", item)
+	if err != nil {
+		return nil, err
+	}
+	var it opItem
+	if err := c.do(ctx, http.MethodGet, "vaults/"+vid+"/items/"+iid, nil, &it); err != nil {
+		if errors.Is(err, errOPNotFound) {
+			return nil, fmt.Errorf("%s: %w", item, ErrKeyNotFound)
+		}
+		return nil, fmt.Errorf("get 1password item: %w", err)
+	}
+	return &it, nil
+}
+
+func (c *opConnect) read(ctx context.Context, vault, item, field string) (string, error) {
+	it, err := c.item(ctx, vault, item)
+	if err != nil {
+		return "", err
+	}
+	f := it.field(field)
+	if f == nil {
+		return "", fmt.Errorf("field %s: %w", field, ErrKeyNotFound)
+	}
+	return f.Value, nil
+}
+
+func (c *opConnect) createItem(ctx context.Context, vault, item, field, value string) error {
+	vid, err := c.vaultID(ctx, vault)
+	if err != nil {
+		return err
+	}
+	payload := map[string]any{
+		"vault":    map[string]string{"id": vid},
+		"title":    item,
+		"category": "API_CREDENTIAL",
+		"fields":   []opField{{Label: field, Type: "CONCEALED", Value: value}},
+	}
+	if err := c.do(ctx, http.MethodPost, "vaults/"+vid+"/items", payload, nil); err != nil {
+		return fmt.Errorf("create 1password item: %w", err)
+	}
+	return nil
+}
+
+// patch applies a JSON Patch to the item, leaving its other attributes untouched.
+func (c *opConnect) patch(ctx context.Context, item *opItem, ops ...map[string]any) error {
+	if err := c.do(ctx, http.MethodPatch, "vaults/"+item.Vault.ID+"/items/"+item.ID, ops, nil); err != nil {
+		return fmt.Errorf("update 1password item: %w", err)
+	}
+	return nil
+}
+
+func (c *opConnect) setField(ctx context.Context, vault string, item *opItem, field, value string) error {
+	if f := item.field(field); f != nil {
+		return c.patch(ctx, item, map[string]any{"op": "replace", "path": "/fields/" + f.ID + "/value", "value": value})
+	}
+	return c.patch(ctx, item, map[string]any{"op": "add", "path": "/fields", "value": opField{Label: field, Type: "CONCEALED", Value: value}})
+}
+
+func (c *opConnect) deleteField(ctx context.Context, vault string, item *opItem, field *opField) error {
+	return c.patch(ctx, item, map[string]any{"op": "remove", "path": "/fields/" + field.ID})
+}
+
+// opCLI shells out to the 1Password CLI, which must be signed in.
+//
+// Values are written as JSON item templates on stdin, never as arguments, which other local users can see.
+type opCLI struct {
+	bin     string
+	account string
+}
+
+func (c *opCLI) run(ctx context.Context, args ...string) ([]byte, error) {
+	return c.runInput(ctx, nil, args...)
+}
+
+// runInput runs op with stdin as its standard input.
+func (c *opCLI) runInput(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	if c.account != "" {
+		args = append(args, "--account", c.account)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.bin, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		if isOPNotFound(msg) {
+			return nil, fmt.Errorf("%s: %w", msg, ErrKeyNotFound)
+		}
+		return nil, fmt.Errorf("op %s: %s", args[0], msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+func isOPNotFound(msg string) bool {
+	for _, s := range []string{"isn't an item", "isn't a vault", "isn't a field", "does not have a field", "not found"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// opFieldSpec formats the field part of an op assignment statement, escaping the field name.
+func opFieldSpec(field, kind string) string {
+	field = strings.NewReplacer(`\`, `\\`, `.`, `\.`, `=`, `\=`).Replace(field)
+	if kind != "" {
+		field += "[" + kind + "]"
+	}
+	return field
+}
+
+func (c *opCLI) read(ctx context.Context, vault, item, field string) (string, error) {
+	out, err := c.run(ctx, "read", "--no-newline", "op://"+vault+"/"+item+"/"+field)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func (c *opCLI) item(ctx context.Context, vault, item string) (*opItem, error) {
+	out, err := c.run(ctx, "item", "get", item, "--vault", vault, "--format", "json")
+	if err != nil {
+		return nil, err
+	}
+	var it opItem
+	if err := json.Unmarshal(out, &it); err != nil {
+		return nil, fmt.Errorf("decode 1password item: %w", err)
+	}
+	it.raw = out
+	return &it, nil
+}
+
+func (c *opCLI) createItem(ctx context.Context, vault, item, field, value string) error {
+	template, err := json.Marshal(map[string]any{
+		"title":    item,
+		"category": "API_CREDENTIAL",
+		"fields":   []opField{{Label: field, Type: "CONCEALED", Value: value}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.runInput(ctx, template, "item", "create", "--vault", vault, "-")
+	return err
+}
+
+// setField edits the item with a template of the whole item, as op replaces the item with it.
+func (c *opCLI) setField(ctx context.Context, vault string, item *opItem, field, value string) error {
+	var template map[string]any
+	if err := json.Unmarshal(item.raw, &template); err != nil {
+		return fmt.Errorf("decode 1password item: %w", err)
+	}
+	fields, _ := template["fields"].([]any)
+	found := false
+	for _, f := range fields {
+		if f, ok := f.(map[string]any); ok && (f["label"] == field || f["id"] == field) {
+			f["value"] = value
+			found = true
+			break
+		}
+	}
+	if !found {
+		fields = append(fields, opField{Label: field, Type: "CONCEALED", Value: value})
+	}
+	template["fields"] = fields
+
+	b, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+	_, err = c.runInput(ctx, b, "item", "edit", item.ID, "--vault", vault)
+	return err
+}
+
+func (c *opCLI) deleteField(ctx context.Context, vault string, item *opItem, field *opField) error {
+	_, err := c.run(ctx, "item", "edit", item.ID, "--vault", vault, opFieldSpec(field.Label, "delete"))
+	return err
+}
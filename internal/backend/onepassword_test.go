@@ -0,0 +1,318 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConnect is an in-memory fake of the 1Password Connect API, holding a single "Private" vault.
+type fakeConnect struct {
+	mu    sync.Mutex
+	items map[string]*opItem
+	next  int
+}
+
+func newFakeConnect(t *testing.T) (*fakeConnect, *httptest.Server) {
+	t.Helper()
+	fc := &fakeConnect{items: make(map[string]*opItem)}
+	srv := httptest.NewServer(fc)
+	t.Cleanup(srv.Close)
+	return fc, srv
+}
+
+func (fc *fakeConnect) add(it *opItem) {
+	fc.next++
+	it.ID = fmt.Sprintf("item%d", fc.next)
+	it.Vault.ID = "vault1"
+	for i := range it.Fields {
+		if it.Fields[i].ID == "" {
+			it.Fields[i].ID = fmt.Sprintf("%s-field%d", it.ID, i)
+		}
+	}
+	fc.items[it.ID] = it
+}
+
+func (fc *fakeConnect) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	reply := func(code int, body any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		if body != nil {
+			json.NewEncoder(w).Encode(body)
+		}
+	}
+	if r.Header.Get("Authorization") != "Bearer token" {
+		reply(http.StatusUnauthorized, map[string]any{"message": "Invalid token"})
+		return
+	}
+	filter := func() string {
+		_, v, _ := strings.Cut(r.URL.Query().Get("filter"), " eq ")
+		s, _ := strconv.Unquote(v)
+		return s
+	}
+	type ref struct {
+		ID string `json:"id"`
+	}
+
+	p := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/"), "/")
+	switch {
+	case len(p) == 1 && p[0] == "vaults":
+		res := []ref{}
+		if filter() == "Private" {
+			res = append(res, ref{"vault1"})
+		}
+		reply(http.StatusOK, res)
+	case len(p) < 3 || p[1] != "vault1":
+		reply(http.StatusNotFound, map[string]any{"message": "vault not found"})
+	case len(p) == 3 && r.Method == http.MethodGet:
+		res := []ref{}
+		for id, it := range fc.items {
+			if it.Title == filter() {
+				res = append(res, ref{id})
+			}
+		}
+		reply(http.StatusOK, res)
+	case len(p) == 3 && r.Method == http.MethodPost:
+		var it opItem
+		json.NewDecoder(r.Body).Decode(&it)
+		fc.add(&it)
+		reply(http.StatusOK, it)
+	case len(p) == 4:
+		it, ok := fc.items[p[3]]
+		if !ok {
+			reply(http.StatusNotFound, map[string]any{"message": "item not found"})
+			return
+		}
+		if r.Method == http.MethodPatch {
+			var ops []struct {
+				Op    string          `json:"op"`
+				Path  string          `json:"path"`
+				Value json.RawMessage `json:"value"`
+			}
+			json.NewDecoder(r.Body).Decode(&ops)
+			for _, op := range ops {
+				fp := strings.Split(op.Path, "/")
+				switch op.Op {
+				case "add":
+					var f opField
+					json.Unmarshal(op.Value, &f)
+					f.ID = fmt.Sprintf("%s-field%d", it.ID, len(it.Fields))
+					it.Fields = append(it.Fields, f)
+				case "replace":
+					json.Unmarshal(op.Value, &it.field(fp[2]).Value)
+				case "remove":
+					for i, f := range it.Fields {
+						if f.ID == fp[2] {
+							it.Fields = append(it.Fields[:i], it.Fields[i+1:]...)
+							break
+						}
+					}
+				}
+			}
+		}
+		reply(http.StatusOK, it)
+	}
+}
+
+func TestOnePasswordStoreBuilder_Build(t *testing.T) {
+	t.Run("cli", func(t *testing.T) {
+		t.Setenv("OP_CONNECT_HOST", "")
+		builder := &OnePasswordStoreBuilder{Account: "team"}
+		s, err := builder.Build(context.TODO(), "test")
+		if assert.NoError(t, err) {
+			assert.Equal(t, &opCLI{bin: "op", account: "team"}, s.(*OnePasswordStore).client)
+		}
+	})
+
+	t.Run("connect", func(t *testing.T) {
+		t.Setenv("OP_CONNECT_HOST", "http://connect:8080/")
+		t.Setenv("OP_CONNECT_TOKEN", "token")
+		builder := &OnePasswordStoreBuilder{}
+		s, err := builder.Build(context.TODO(), "test")
+		if assert.NoError(t, err) {
+			c := s.(*OnePasswordStore).client.(*opConnect)
+			assert.Equal(t, "http://connect:8080", c.host)
+			assert.Equal(t, "token", c.token)
+		}
+	})
+
+	t.Run("connect without token", func(t *testing.T) {
+		t.Setenv("OP_CONNECT_TOKEN", "")
+		builder := &OnePasswordStoreBuilder{ConnectHost: "http://connect:8080"}
+		_, err := builder.Build(context.TODO(), "test")
+		assert.EqualError(t, err, "missing connect token: set OP_CONNECT_TOKEN")
+	})
+}
+
+func TestOnePasswordStore_Connect(t *testing.T) {
+	fc, srv := newFakeConnect(t)
+	fc.add(&opItem{Title: "GitHub", Fields: []opField{
+		{ID: "username", Label: "username", Type: "STRING", Value: "octocat"},
+		{Label: "token", Type: "CONCEALED", Value: "ghp_123"},
+	}})
+	store := &OnePasswordStore{&opConnect{http: srv.Client(), host: srv.URL, token: "token"}}
+	ctx := context.TODO()
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := store.Get(ctx, "GitHub/token")
+		assert.EqualError(t, err, "invalid key GitHub/token: expected vault/item/field")
+	})
+
+	t.Run("get", func(t *testing.T) {
+		v, err := store.Get(ctx, "Private/GitHub/token")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "ghp_123", v)
+		}
+	})
+
+	t.Run("get not found", func(t *testing.T) {
+		for _, k := range []string{"Work/GitHub/token", "Private/GitLab/token", "Private/GitHub/password"} {
+			_, err := store.Get(ctx, k)
+			assert.ErrorIs(t, err, ErrKeyNotFound, k)
+		}
+	})
+
+	t.Run("set existing field", func(t *testing.T) {
+		require.NoError(t, store.Set(ctx, "Private/GitHub/token", "ghp_456"))
+		v, err := store.Get(ctx, "Private/GitHub/token")
+		require.NoError(t, err)
+		assert.Equal(t, "ghp_456", v)
+		assert.Len(t, fc.items["item1"].Fields, 2)
+	})
+
+	t.Run("set new field", func(t *testing.T) {
+		require.NoError(t, store.Set(ctx, "Private/GitHub/pat", "github_pat_1"))
+		f := fc.items["item1"].field("pat")
+		if assert.NotNil(t, f) {
+			assert.Equal(t, opField{ID: f.ID, Label: "pat", Type: "CONCEALED", Value: "github_pat_1"}, *f)
+		}
+	})
+
+	t.Run("set new item", func(t *testing.T) {
+		require.NoError(t, store.Set(ctx, "Private/npm/token", "npm_1"))
+		v, err := store.Get(ctx, "Private/npm/token")
+		require.NoError(t, err)
+		assert.Equal(t, "npm_1", v)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, "Private/GitHub/pat"))
+		assert.Nil(t, fc.items["item1"].field("pat"))
+		assert.NotNil(t, fc.items["item1"].field("username"))
+
+		assert.NoError(t, store.Delete(ctx, "Private/GitHub/pat"))
+		assert.NoError(t, store.Delete(ctx, "Private/GitLab/token"))
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		denied := &OnePasswordStore{&opConnect{http: srv.Client(), host: srv.URL, token: "wrong"}}
+		_, err := denied.Get(ctx, "Private/GitHub/token")
+		assert.ErrorContains(t, err, "401 Unauthorized: Invalid token")
+	})
+}
+
+// fakeOP writes an op executable that logs its arguments, one per line, saves its stdin next to them,
+// and replies with the given script.
+func fakeOP(t *testing.T, script string) (*opCLI, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake op CLI requires a POSIX shell")
+	}
+	dir := t.TempDir()
+	log := filepath.Join(dir, "args")
+	bin := filepath.Join(dir, "op")
+	content := fmt.Sprintf("#!/bin/sh\nprintf '%%s\\n' \"$@\" > %s\ncat > %s.stdin\n%s\n", log, log, script)
+	require.NoError(t, os.WriteFile(bin, []byte(content), 0o755))
+	return &opCLI{bin: bin}, log
+}
+
+func readArgs(t *testing.T, log string) []string {
+	t.Helper()
+	b, err := os.ReadFile(log)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+}
+
+// readTemplate returns the item template written on the stdin of the last op call.
+func readTemplate(t *testing.T, log string) map[string]any {
+	t.Helper()
+	b, err := os.ReadFile(log + ".stdin")
+	require.NoError(t, err)
+	var template map[string]any
+	require.NoError(t, json.Unmarshal(b, &template))
+	return template
+}
+
+func TestOnePasswordStore_CLI(t *testing.T) {
+	ctx := context.TODO()
+	item := `{"id":"abc","title":"GitHub","vault":{"id":"v1"},"urls":[{"href":"https://github.com"}],"fields":[{"id":"f1","label":"api.token","type":"CONCEALED"}]}`
+
+	t.Run("get", func(t *testing.T) {
+		client, log := fakeOP(t, "printf 'ghp_123'")
+		client.account = "team"
+		v, err := (&OnePasswordStore{client}).Get(ctx, "Private/GitHub/token")
+		require.NoError(t, err)
+		assert.Equal(t, "ghp_123", v)
+		assert.Equal(t, []string{"read", "--no-newline", "op://Private/GitHub/token", "--account", "team"}, readArgs(t, log))
+	})
+
+	t.Run("get not found", func(t *testing.T) {
+		client, _ := fakeOP(t, `echo '[ERROR] "GitLab" isn'"'"'t an item in the "Private" vault.' >&2; exit 1`)
+		_, err := (&OnePasswordStore{client}).Get(ctx, "Private/GitLab/token")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		client, _ := fakeOP(t, `echo '[ERROR] You are not currently signed in.' >&2; exit 1`)
+		_, err := (&OnePasswordStore{client}).Get(ctx, "Private/GitHub/token")
+		assert.EqualError(t, err, "op read: [ERROR] You are not currently signed in.")
+	})
+
+	t.Run("set existing field", func(t *testing.T) {
+		client, log := fakeOP(t, fmt.Sprintf("[ \"$2\" = get ] && echo '%s'; true", item))
+		require.NoError(t, (&OnePasswordStore{client}).Set(ctx, "Private/GitHub/api.token", "ghp_456"))
+		assert.Equal(t, []string{"item", "edit", "abc", "--vault", "Private"}, readArgs(t, log), "values are not arguments")
+		template := readTemplate(t, log)
+		assert.Equal(t, []any{map[string]any{"id": "f1", "label": "api.token", "type": "CONCEALED", "value": "ghp_456"}}, template["fields"])
+		assert.Equal(t, []any{map[string]any{"href": "https://github.com"}}, template["urls"], "other attributes are kept")
+	})
+
+	t.Run("set new field", func(t *testing.T) {
+		client, log := fakeOP(t, fmt.Sprintf("[ \"$2\" = get ] && echo '%s'; true", item))
+		require.NoError(t, (&OnePasswordStore{client}).Set(ctx, "Private/GitHub/pat", "github_pat_1"))
+		assert.Equal(t, []string{"item", "edit", "abc", "--vault", "Private"}, readArgs(t, log))
+		assert.Equal(t, map[string]any{"label": "pat", "type": "CONCEALED", "value": "github_pat_1"}, readTemplate(t, log)["fields"].([]any)[1])
+	})
+
+	t.Run("set new item", func(t *testing.T) {
+		client, log := fakeOP(t, `if [ "$2" = get ]; then echo '[ERROR] "npm" isn'"'"'t an item.' >&2; exit 1; fi`)
+		require.NoError(t, (&OnePasswordStore{client}).Set(ctx, "Private/npm/token", "npm_1"))
+		assert.Equal(t, []string{"item", "create", "--vault", "Private", "-"}, readArgs(t, log))
+		assert.Equal(t, map[string]any{
+			"title":    "npm",
+			"category": "API_CREDENTIAL",
+			"fields":   []any{map[string]any{"label": "token", "type": "CONCEALED", "value": "npm_1"}},
+		}, readTemplate(t, log))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		client, log := fakeOP(t, fmt.Sprintf("[ \"$2\" = get ] && echo '%s'; true", item))
+		require.NoError(t, (&OnePasswordStore{client}).Delete(ctx, "Private/GitHub/f1"))
+		assert.Equal(t, []string{"item", "edit", "abc", "--vault", "Private", `api\.token[delete]`}, readArgs(t, log))
+	})
+}
@@ -2,6 +2,7 @@ package backend
 
 import (
 	"context"
+	"errors"
 
 	"github.com/zalando/go-keyring"
 )
@@ -64,6 +65,9 @@ func newOSStore(namespace string) *OSStore {
 // Get implements the Store.Get method.
 func (o *OSStore) Get(ctx context.Context, k string) (string, error) {
 	secret, err := keyring.Get(o.service, k)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrKeyNotFound
+	}
 	if err != nil {
 		return "", err
 	}
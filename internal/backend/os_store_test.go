@@ -72,6 +72,13 @@ func TestOSStore_Get(t *testing.T) {
 		}
 	})
 
+	t.Run("not found", func(t *testing.T) {
+		keyring.MockInitWithError(keyring.ErrNotFound)
+
+		_, err := newOSStore("test_foo").Get(context.TODO(), "test_no_var")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
 	t.Run("nominal", func(t *testing.T) {
 		keyring.MockInit()
 
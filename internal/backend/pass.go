@@ -0,0 +1,229 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+func init() {
+	registerBuilder("pass", func() Builder { return new(PassStoreBuilder) })
+}
+
+// PassStoreBuilder implements the Builder interface for PassStore.
+type PassStoreBuilder struct {
+	// Dir is the password store directory, defaults to PASSWORD_STORE_DIR or ~/.password-store.
+	Dir string `toml:"dir,omitempty"`
+	// GPG is the gpg executable, defaults to "gpg".
+	GPG string `toml:"gpg,omitempty"`
+}
+
+// Build returns a new PassStore store.
+func (pb *PassStoreBuilder) Build(ctx context.Context, name string) (Store, error) {
+	dir := firstNonEmpty(pb.Dir, os.Getenv("PASSWORD_STORE_DIR"))
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("could not locate password store: %w", err)
+		}
+		dir = filepath.Join(home, ".password-store")
+	}
+	return NewPassStore(dir, firstNonEmpty(pb.GPG, "gpg"))
+}
+
+// PassStore reads and writes a pass (https://www.passwordstore.org) compatible password store,
+// a tree of gpg encrypted files, each one holding an entry.
+//
+// Following the pass convention, the value of a key is the first line of its entry.
+// Set replaces that line and preserves the rest of the entry.
+// New entries are encrypted for the recipients listed in the nearest .gpg-id file.
+// Changes are not committed to the store git repository, if any.
+type PassStore struct {
+	dir string
+	gpg string
+}
+
+// NewPassStore creates a new PassStore for the password store at dir, using the gpg executable.
+func NewPassStore(dir, gpg string) (*PassStore, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("open password store: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("open password store: %s is not a directory", dir)
+	}
+	return &PassStore{filepath.Clean(dir), gpg}, nil
+}
+
+func (p *PassStore) entry(k string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(k)) {
+		return "", fmt.Errorf("invalid key %s", k)
+	}
+	return filepath.Join(p.dir, filepath.FromSlash(k)+".gpg"), nil
+}
+
+func (p *PassStore) run(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.gpg, append([]string{"--quiet", "--yes", "--compress-algo=none", "--no-encrypt-to"}, args...)...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, errors.New(msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+func (p *PassStore) decrypt(ctx context.Context, file string) ([]byte, error) {
+	sealed, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	plain, err := p.run(ctx, sealed, "--decrypt")
+	if err != nil {
+		return nil, fmt.Errorf("decrypt entry: %w", err)
+	}
+	return plain, nil
+}
+
+// recipients returns the gpg ids of the nearest .gpg-id file, from the directory of file up to the store root.
+func (p *PassStore) recipients(file string) ([]string, error) {
+	for dir := filepath.Dir(file); ; dir = filepath.Dir(dir) {
+		b, err := os.ReadFile(filepath.Join(dir, ".gpg-id"))
+		if err == nil {
+			var ids []string
+			for _, line := range strings.Split(string(b), "\n") {
+				if line, _, _ = strings.Cut(line, "#"); strings.TrimSpace(line) != "" {
+					ids = append(ids, strings.TrimSpace(line))
+				}
+			}
+			if len(ids) == 0 {
+				return nil, fmt.Errorf("empty %s", filepath.Join(dir, ".gpg-id"))
+			}
+			return ids, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		if dir == p.dir {
+			return nil, fmt.Errorf("no .gpg-id found in %s, run pass init", p.dir)
+		}
+	}
+}
+
+// Get implements the Store.Get method.
+func (p *PassStore) Get(ctx context.Context, k string) (string, error) {
+	file, err := p.entry(k)
+	if err != nil {
+		return "", err
+	}
+	plain, err := p.decrypt(ctx, file)
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(string(plain), "\n")
+	return line, nil
+}
+
+// Set implements the Store.Set method.
+func (p *PassStore) Set(ctx context.Context, k, v string) error {
+	file, err := p.entry(k)
+	if err != nil {
+		return err
+	}
+
+	entry := v + "\n"
+	plain, err := p.decrypt(ctx, file)
+	switch {
+	case err == nil:
+		if _, rest, ok := strings.Cut(string(plain), "\n"); ok {
+			entry += rest
+		}
+	case !errors.Is(err, ErrKeyNotFound):
+		return err
+	}
+
+	ids, err := p.recipients(file)
+	if err != nil {
+		return err
+	}
+	args := []string{"--encrypt"}
+	for _, id := range ids {
+		args = append(args, "--recipient", id)
+	}
+	sealed, err := p.run(ctx, []byte(entry), args...)
+	if err != nil {
+		return fmt.Errorf("encrypt entry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	return writeFileAtomic(file, sealed)
+}
+
+// Delete implements the Store.Delete method.
+// Directories left empty are removed, like pass rm does.
+func (p *PassStore) Delete(ctx context.Context, k string) error {
+	file, err := p.entry(k)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for dir := filepath.Dir(file); dir != p.dir; dir = filepath.Dir(dir) {
+		// Fails on the first non-empty directory
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// List implements the Lister.List method.
+func (p *PassStore) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(p.dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && file != p.dir {
+			// Skip .git and other hidden entries
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".gpg") {
+			return nil
+		}
+		rel, err := filepath.Rel(p.dir, file)
+		if err != nil {
+			return err
+		}
+		if k := filepath.ToSlash(strings.TrimSuffix(rel, ".gpg")); strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
@@ -0,0 +1,135 @@
+package backend
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPassStore initializes a password store for a throwaway gpg key, in an isolated GNUPGHOME.
+func newTestPassStore(t *testing.T) *PassStore {
+	t.Helper()
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg is not installed")
+	}
+
+	// Keep the agent socket path short
+	home, err := os.MkdirTemp("", "gpg")
+	require.NoError(t, err)
+	t.Setenv("GNUPGHOME", home)
+	t.Cleanup(func() {
+		exec.Command("gpgconf", "--kill", "gpg-agent").Run()
+		os.RemoveAll(home)
+	})
+	out, err := exec.Command("gpg", "--batch", "--passphrase", "", "--quick-generate-key", "clef <clef@example.com>", "future-default", "default", "never").CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gpg-id"), []byte("clef@example.com\n"), 0600))
+	store, err := NewPassStore(dir, "gpg")
+	require.NoError(t, err)
+	return store
+}
+
+func TestPassStoreBuilder_Build(t *testing.T) {
+	t.Run("from env", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("PASSWORD_STORE_DIR", dir)
+		builder := &PassStoreBuilder{}
+		s, err := builder.Build(context.TODO(), "test")
+		if assert.NoError(t, err) {
+			assert.Equal(t, &PassStore{dir, "gpg"}, s)
+		}
+	})
+
+	t.Run("missing dir", func(t *testing.T) {
+		builder := &PassStoreBuilder{Dir: filepath.Join(t.TempDir(), "nope")}
+		_, err := builder.Build(context.TODO(), "test")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestPassStore_recipients(t *testing.T) {
+	dir := t.TempDir()
+	store := &PassStore{dir: dir}
+
+	_, err := store.recipients(filepath.Join(dir, "foo.gpg"))
+	assert.EqualError(t, err, "no .gpg-id found in "+dir+", run pass init")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gpg-id"), []byte("alice@example.com\n"), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "team", "infra"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "team", ".gpg-id"), []byte("# team\nalice@example.com\n\nbob@example.com # ops\n"), 0600))
+
+	ids, err := store.recipients(filepath.Join(dir, "foo.gpg"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice@example.com"}, ids)
+
+	ids, err = store.recipients(filepath.Join(dir, "team", "infra", "db.gpg"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice@example.com", "bob@example.com"}, ids)
+}
+
+func TestPassStore(t *testing.T) {
+	store := newTestPassStore(t)
+	ctx := context.TODO()
+
+	t.Run("get not found", func(t *testing.T) {
+		_, err := store.Get(ctx, "nokey")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := store.Get(ctx, "../outside")
+		assert.EqualError(t, err, "invalid key ../outside")
+	})
+
+	t.Run("set and get", func(t *testing.T) {
+		require.NoError(t, store.Set(ctx, "web/github", "hunter2"))
+		assert.FileExists(t, filepath.Join(store.dir, "web", "github.gpg"))
+
+		v, err := store.Get(ctx, "web/github")
+		require.NoError(t, err)
+		assert.Equal(t, "hunter2", v)
+	})
+
+	t.Run("set preserves extra lines", func(t *testing.T) {
+		sealed, err := store.run(ctx, []byte("old\nlogin: octocat\n"), "--encrypt", "--recipient", "clef@example.com")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(store.dir, "web", "multi.gpg"), sealed, 0600))
+
+		v, err := store.Get(ctx, "web/multi")
+		require.NoError(t, err)
+		assert.Equal(t, "old", v)
+
+		require.NoError(t, store.Set(ctx, "web/multi", "new"))
+		plain, err := store.decrypt(ctx, filepath.Join(store.dir, "web", "multi.gpg"))
+		require.NoError(t, err)
+		assert.Equal(t, "new\nlogin: octocat\n", string(plain))
+	})
+
+	t.Run("list", func(t *testing.T) {
+		require.NoError(t, store.Set(ctx, "email", "x"))
+		require.NoError(t, os.MkdirAll(filepath.Join(store.dir, ".git"), 0700))
+		require.NoError(t, os.WriteFile(filepath.Join(store.dir, ".git", "ignored.gpg"), nil, 0600))
+
+		keys, err := store.List(ctx, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"email", "web/github", "web/multi"}, keys)
+
+		keys, err = store.List(ctx, "web/")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"web/github", "web/multi"}, keys)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, "web/github"))
+		require.NoError(t, store.Delete(ctx, "web/multi"))
+		assert.NoDirExists(t, filepath.Join(store.dir, "web"))
+		assert.NoError(t, store.Delete(ctx, "web/multi"))
+	})
+}
@@ -0,0 +1,212 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// PluginProtocolVersion is the version of the plugin protocol spoken by clef.
+const PluginProtocolVersion = 1
+
+// pluginPrefix prefixes the name of plugin executables.
+const pluginPrefix = "clef-store-"
+
+func init() {
+	registerBuilder("plugin", func() Builder { return new(PluginStoreBuilder) })
+}
+
+// PluginStoreBuilder implements the Builder interface for PluginStore.
+//
+// It decodes the whole store config table: the plugin key names the plugin, defaulting to the store name,
+// and every other key is forwarded to the plugin as is.
+type PluginStoreBuilder struct {
+	Plugin string
+	Config map[string]any
+}
+
+// UnmarshalTOML implements the toml.Unmarshaler interface.
+func (pb *PluginStoreBuilder) UnmarshalTOML(data any) error {
+	pb.Config = make(map[string]any)
+	if data == nil {
+		return nil
+	}
+	table, ok := data.(map[string]any)
+	if !ok {
+		return fmt.Errorf("plugin config must be a table, got %T", data)
+	}
+	for k, v := range table {
+		if k == "plugin" {
+			if pb.Plugin, ok = v.(string); !ok {
+				return fmt.Errorf("plugin must be a string, got %T", v)
+			}
+			continue
+		}
+		pb.Config[k] = v
+	}
+	return nil
+}
+
+// Build returns a new PluginStore store.
+func (pb *PluginStoreBuilder) Build(ctx context.Context, name string) (Store, error) {
+	bin, err := exec.LookPath(pluginPrefix + firstNonEmpty(pb.Plugin, name))
+	if err != nil {
+		return nil, fmt.Errorf("find plugin: %w", err)
+	}
+	return NewPluginStore(ctx, bin, name, pb.Config)
+}
+
+// PluginStore forwards the store operations to an external plugin executable,
+// so that stores can be added without changing clef.
+//
+// The plugin is started once, and kept running until the store is closed.
+// clef writes one JSON request per line to its stdin, and reads one JSON response per line from its stdout.
+// The plugin stderr is clef's own, for logs and prompts.
+//
+// The first request is {"op":"init","protocol":1,"name":"<store name>","config":{...}}.
+// The others are {"op":"get","key":"k"}, {"op":"set","key":"k","value":"v"}, {"op":"delete","key":"k"}
+// and {"op":"list","prefix":"p"}.
+// Responses hold the "value" of get, the "keys" of list, or an "error" message.
+// Errors may carry a "code": "not_found" for missing keys (delete must succeed instead),
+// "read_only" to refuse writes and "unsupported" for operations the plugin doesn't implement.
+type PluginStore struct {
+	name string
+	cmd  *exec.Cmd
+	in   io.WriteCloser
+	enc  *json.Encoder
+	dec  *json.Decoder
+
+	mu sync.Mutex
+	// err is set once the plugin is unusable
+	err error
+}
+
+type pluginRequest struct {
+	Op       string         `json:"op"`
+	Protocol int            `json:"protocol,omitempty"`
+	Name     string         `json:"name,omitempty"`
+	Config   map[string]any `json:"config,omitempty"`
+	Key      string         `json:"key,omitempty"`
+	Value    *string        `json:"value,omitempty"`
+	Prefix   *string        `json:"prefix,omitempty"`
+}
+
+type pluginResponse struct {
+	Value string   `json:"value"`
+	Keys  []string `json:"keys"`
+	Error string   `json:"error"`
+	Code  string   `json:"code"`
+}
+
+// NewPluginStore starts the plugin executable bin and initializes it with the store name and config.
+func NewPluginStore(ctx context.Context, bin, name string, config map[string]any) (*PluginStore, error) {
+	// Not bound to ctx, the plugin outlives the build
+	cmd := exec.Command(bin)
+	cmd.Stderr = os.Stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start plugin: %w", err)
+	}
+
+	ps := &PluginStore{
+		name: name,
+		cmd:  cmd,
+		in:   in,
+		enc:  json.NewEncoder(in),
+		dec:  json.NewDecoder(bufio.NewReader(out)),
+	}
+	if _, err := ps.call(ctx, pluginRequest{Op: "init", Protocol: PluginProtocolVersion, Name: name, Config: config}); err != nil {
+		ps.Close()
+		return nil, fmt.Errorf("init plugin: %w", err)
+	}
+	return ps, nil
+}
+
+// call sends req to the plugin and returns its response.
+// The plugin is killed if ctx is done before it responds.
+func (ps *PluginStore) call(ctx context.Context, req pluginRequest) (pluginResponse, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.err != nil {
+		return pluginResponse{}, ps.err
+	}
+
+	stop := context.AfterFunc(ctx, func() { ps.cmd.Process.Kill() })
+	defer stop()
+
+	var resp pluginResponse
+	err := ps.enc.Encode(req)
+	if err == nil {
+		err = ps.dec.Decode(&resp)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		} else if errors.Is(err, io.EOF) {
+			err = errors.New("plugin exited")
+		}
+		ps.err = fmt.Errorf("plugin %s: %w", ps.name, err)
+		return pluginResponse{}, ps.err
+	}
+
+	switch {
+	case resp.Code == "not_found":
+		return resp, ErrKeyNotFound
+	case resp.Code == "read_only":
+		return resp, ErrReadOnly
+	case resp.Code == "unsupported":
+		return resp, fmt.Errorf("plugin %s does not support %s", ps.name, req.Op)
+	case resp.Error != "":
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// Close stops the plugin, closing its stdin and waiting for it to exit.
+func (ps *PluginStore) Close() error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.err == nil {
+		ps.err = fmt.Errorf("plugin %s: closed", ps.name)
+	}
+	ps.in.Close()
+	return ps.cmd.Wait()
+}
+
+// Get implements the Store.Get method.
+func (ps *PluginStore) Get(ctx context.Context, k string) (string, error) {
+	resp, err := ps.call(ctx, pluginRequest{Op: "get", Key: k})
+	return resp.Value, err
+}
+
+// Set implements the Store.Set method.
+func (ps *PluginStore) Set(ctx context.Context, k, v string) error {
+	_, err := ps.call(ctx, pluginRequest{Op: "set", Key: k, Value: &v})
+	return err
+}
+
+// Delete implements the Store.Delete method.
+func (ps *PluginStore) Delete(ctx context.Context, k string) error {
+	_, err := ps.call(ctx, pluginRequest{Op: "delete", Key: k})
+	return err
+}
+
+// List implements the Lister.List method.
+// Plugins are expected to return sorted keys.
+func (ps *PluginStore) List(ctx context.Context, prefix string) ([]string, error) {
+	resp, err := ps.call(ctx, pluginRequest{Op: "list", Prefix: &prefix})
+	return resp.Keys, err
+}
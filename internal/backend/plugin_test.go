@@ -0,0 +1,151 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePlugin writes a clef-store-fake executable to a directory added to PATH.
+// The requests it receives are appended to the returned file.
+func fakePlugin(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake plugin requires a POSIX shell")
+	}
+	dir := t.TempDir()
+	requests := filepath.Join(dir, "requests")
+	script := fmt.Sprintf(`#!/bin/sh
+while IFS= read -r line; do
+  echo "$line" >> %s
+  case "$line" in
+  *'"op":"init"'*) echo '{}';;
+  *'"key":"github"'*) echo '{"value":"hunter2"}';;
+  *'"key":"locked"'*) echo '{"error":"store is sealed","code":"read_only"}';;
+  *'"key":"slow"'*) exec sleep 10;;
+  *'"key":"crash"'*) exit 1;;
+  *'"op":"get"'*) echo '{"error":"no such secret","code":"not_found"}';;
+  *'"op":"list"'*) echo '{"error":"not implemented","code":"unsupported"}';;
+  *) echo '{}';;
+  esac
+done
+`, requests)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, pluginPrefix+"fake"), []byte(script), 0o755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return requests
+}
+
+func readRequests(t *testing.T, file string) []string {
+	t.Helper()
+	b, err := os.ReadFile(file)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSpace(string(b)), "\n")
+}
+
+func TestPluginStoreBuilder_UnmarshalTOML(t *testing.T) {
+	var pb PluginStoreBuilder
+	_, err := toml.Decode(`
+plugin = "fake"
+endpoint = "https://secrets.internal"
+retries = 3
+[auth]
+method = "oidc"
+`, &pb)
+	require.NoError(t, err)
+	assert.Equal(t, "fake", pb.Plugin)
+	assert.Equal(t, map[string]any{
+		"endpoint": "https://secrets.internal",
+		"retries":  int64(3),
+		"auth":     map[string]any{"method": "oidc"},
+	}, pb.Config)
+
+	_, err = toml.Decode(`plugin = 1`, &pb)
+	assert.ErrorContains(t, err, "plugin must be a string, got int64")
+}
+
+func TestPluginStoreBuilder_Build(t *testing.T) {
+	requests := fakePlugin(t)
+	ctx := context.TODO()
+
+	pb := &PluginStoreBuilder{Config: map[string]any{"endpoint": "https://secrets.internal"}}
+	store, err := pb.Build(ctx, "fake")
+	require.NoError(t, err)
+	require.NoError(t, store.(*PluginStore).Close())
+	assert.Equal(t, []string{
+		`{"op":"init","protocol":1,"name":"fake","config":{"endpoint":"https://secrets.internal"}}`,
+	}, readRequests(t, requests))
+
+	_, err = (&PluginStoreBuilder{Plugin: "missing"}).Build(ctx, "fake")
+	assert.ErrorContains(t, err, "find plugin")
+}
+
+func TestPluginStore(t *testing.T) {
+	requests := fakePlugin(t)
+	ctx := context.TODO()
+
+	store, err := (&PluginStoreBuilder{}).Build(ctx, "fake")
+	require.NoError(t, err)
+	ps := store.(*PluginStore)
+	defer ps.Close()
+
+	v, err := ps.Get(ctx, "github")
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", v)
+
+	_, err = ps.Get(ctx, "gitlab")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	assert.NoError(t, ps.Set(ctx, "npm", ""))
+	assert.ErrorIs(t, ps.Set(ctx, "locked", "x"), ErrReadOnly)
+	assert.NoError(t, ps.Delete(ctx, "npm"))
+
+	_, err = ps.List(ctx, "")
+	assert.EqualError(t, err, "plugin fake does not support list")
+
+	assert.Equal(t, []string{
+		`{"op":"init","protocol":1,"name":"fake"}`,
+		`{"op":"get","key":"github"}`,
+		`{"op":"get","key":"gitlab"}`,
+		`{"op":"set","key":"npm","value":""}`,
+		`{"op":"set","key":"locked","value":"x"}`,
+		`{"op":"delete","key":"npm"}`,
+		`{"op":"list","prefix":""}`,
+	}, readRequests(t, requests))
+}
+
+func TestPluginStore_failures(t *testing.T) {
+	fakePlugin(t)
+
+	t.Run("crash", func(t *testing.T) {
+		store, err := (&PluginStoreBuilder{}).Build(context.TODO(), "fake")
+		require.NoError(t, err)
+		ps := store.(*PluginStore)
+
+		_, err = ps.Get(context.TODO(), "crash")
+		assert.EqualError(t, err, "plugin fake: plugin exited")
+		_, err = ps.Get(context.TODO(), "github")
+		assert.EqualError(t, err, "plugin fake: plugin exited", "the plugin is not restarted")
+		assert.Error(t, ps.Close())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		store, err := (&PluginStoreBuilder{}).Build(context.TODO(), "fake")
+		require.NoError(t, err)
+		ps := store.(*PluginStore)
+
+		ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+		defer cancel()
+		_, err = ps.Get(ctx, "slow")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Error(t, ps.Close())
+	})
+}
@@ -0,0 +1,44 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// ErrNotInteractive means a secret had to be prompted, but there is no terminal.
+var ErrNotInteractive = errors.New("not running interactively")
+
+// Prompt prints prompt on stderr and reads a non-empty secret from the terminal, without echo.
+// The terminal is opened directly rather than read through stdin, so that prompts work while stdin is piped.
+func Prompt(prompt string) ([]byte, error) {
+	tty, err := openTTY()
+	if err != nil {
+		return nil, ErrNotInteractive
+	}
+	defer tty.Close()
+	fd := int(tty.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, ErrNotInteractive
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	b, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("read from terminal: %w", err)
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("empty input")
+	}
+	return b, nil
+}
+
+// promptSecret is [Prompt] returning a string.
+// It is a variable so that tests can answer prompts.
+var promptSecret = func(prompt string) (string, error) {
+	b, err := Prompt(prompt)
+	return string(b), err
+}
@@ -0,0 +1,315 @@
+//go:build linux
+
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func init() {
+	registerBuilder("secretservice", func() Builder { return new(SecretServiceStoreBuilder) })
+}
+
+const (
+	ssDest  = "org.freedesktop.secrets"
+	ssPath  = dbus.ObjectPath("/org/freedesktop/secrets")
+	ssIface = "org.freedesktop.Secret."
+	// ssNoPrompt is the object path returned when no prompt is needed.
+	ssNoPrompt = dbus.ObjectPath("/")
+)
+
+// ssSecret is the Secret Service secret struct.
+type ssSecret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// SecretServiceStoreBuilder implements the Builder interface for SecretServiceStore.
+type SecretServiceStoreBuilder struct {
+	// Collection is the label or alias of the collection holding the secrets, defaults to the "default" alias.
+	// It is created if missing.
+	Collection string `toml:"collection,omitempty"`
+	// Namespace defaults to the store name.
+	Namespace string `toml:"namespace,omitempty"`
+	// Label is the label of new items, where {key} is replaced by the key.
+	Label string `toml:"label,omitempty"`
+	// Attributes are set on every item, and used to look them up.
+	Attributes map[string]string `toml:"attributes,omitempty"`
+}
+
+// Build returns a new SecretServiceStore store.
+func (sb *SecretServiceStoreBuilder) Build(ctx context.Context, name string) (Store, error) {
+	namespace := firstNonEmpty(sb.Namespace, name)
+	if namespace == SystemStoreNameSpace {
+		return nil, ErrReservedStoreName
+	}
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("connect to session bus: %w", err)
+	}
+	store, err := NewSecretServiceStore(ctx, conn, sb.Collection, namespace, sb.Label, sb.Attributes)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// SecretServiceStore uses the freedesktop.org [Secret Service] D-Bus API to store secrets.
+//
+// Items are looked up by the service ("clef:<namespace>") and username (the key) attributes, like OSStore does,
+// so both stores share their secrets when they use the same collection.
+// Unlike OSStore, the collection can be chosen, and it is created if missing.
+// Locked collections are unlocked on load, which may prompt the user.
+//
+// [Secret Service]: https://specifications.freedesktop.org/secret-service/latest/
+type SecretServiceStore struct {
+	conn       *dbus.Conn
+	session    dbus.ObjectPath
+	collection dbus.ObjectPath
+	label      string
+	attributes map[string]string
+}
+
+// NewSecretServiceStore creates a new SecretServiceStore over conn.
+// An empty collection defaults to the "default" alias, and an empty label to the one used by OSStore.
+func NewSecretServiceStore(ctx context.Context, conn *dbus.Conn, collection, namespace, label string, attributes map[string]string) (*SecretServiceStore, error) {
+	service := "clef:" + namespace
+	ss := &SecretServiceStore{
+		conn:       conn,
+		label:      firstNonEmpty(label, "Password for '{key}' on '"+service+"'"),
+		attributes: map[string]string{"service": service},
+	}
+	for k, v := range attributes {
+		ss.attributes[k] = v
+	}
+
+	var out dbus.Variant
+	err := conn.Object(ssDest, ssPath).CallWithContext(ctx, ssIface+"Service.OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&out, &ss.session)
+	if err != nil {
+		return nil, fmt.Errorf("open secret service session: %w", err)
+	}
+
+	name := firstNonEmpty(collection, "default")
+	if ss.collection, err = ss.findCollection(ctx, name); err != nil {
+		return nil, err
+	}
+	if ss.collection == ssNoPrompt {
+		if ss.collection, err = ss.createCollection(ctx, name); err != nil {
+			return nil, fmt.Errorf("create collection %s: %w", name, err)
+		}
+	}
+	if err := ss.unlock(ctx); err != nil {
+		return nil, fmt.Errorf("unlock collection %s: %w", name, err)
+	}
+	return ss, nil
+}
+
+func (ss *SecretServiceStore) service() dbus.BusObject {
+	return ss.conn.Object(ssDest, ssPath)
+}
+
+// findCollection returns the collection with the alias or label name, or "/".
+func (ss *SecretServiceStore) findCollection(ctx context.Context, name string) (dbus.ObjectPath, error) {
+	var path dbus.ObjectPath
+	if err := ss.service().CallWithContext(ctx, ssIface+"Service.ReadAlias", 0, name).Store(&path); err != nil {
+		return "", fmt.Errorf("read collection alias: %w", err)
+	}
+	if path != ssNoPrompt {
+		return path, nil
+	}
+
+	v, err := ss.service().GetProperty(ssIface + "Service.Collections")
+	if err != nil {
+		return "", fmt.Errorf("list collections: %w", err)
+	}
+	paths, _ := v.Value().([]dbus.ObjectPath)
+	for _, p := range paths {
+		label, err := ss.conn.Object(ssDest, p).GetProperty(ssIface + "Collection.Label")
+		if err != nil {
+			return "", fmt.Errorf("read collection label: %w", err)
+		}
+		if label.Value() == name {
+			return p, nil
+		}
+	}
+	return ssNoPrompt, nil
+}
+
+func (ss *SecretServiceStore) createCollection(ctx context.Context, name string) (dbus.ObjectPath, error) {
+	props := map[string]dbus.Variant{ssIface + "Collection.Label": dbus.MakeVariant(name)}
+	alias := ""
+	if name == "default" {
+		alias = name
+	}
+	var path, prompt dbus.ObjectPath
+	if err := ss.service().CallWithContext(ctx, ssIface+"Service.CreateCollection", 0, props, alias).Store(&path, &prompt); err != nil {
+		return "", err
+	}
+	result, err := ss.prompt(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+	if prompt != ssNoPrompt {
+		path, _ = result.Value().(dbus.ObjectPath)
+	}
+	return path, nil
+}
+
+func (ss *SecretServiceStore) unlock(ctx context.Context) error {
+	locked, err := ss.conn.Object(ssDest, ss.collection).GetProperty(ssIface + "Collection.Locked")
+	if err != nil {
+		return err
+	}
+	if locked.Value() != true {
+		return nil
+	}
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	if err := ss.service().CallWithContext(ctx, ssIface+"Service.Unlock", 0, []dbus.ObjectPath{ss.collection}).Store(&unlocked, &prompt); err != nil {
+		return err
+	}
+	_, err = ss.prompt(ctx, prompt)
+	return err
+}
+
+// prompt shows the prompt at path, if any, and waits for its completion.
+// It returns the prompt result.
+func (ss *SecretServiceStore) prompt(ctx context.Context, path dbus.ObjectPath) (dbus.Variant, error) {
+	if path == ssNoPrompt {
+		return dbus.Variant{}, nil
+	}
+
+	opts := []dbus.MatchOption{dbus.WithMatchObjectPath(path), dbus.WithMatchInterface(ssIface + "Prompt"), dbus.WithMatchMember("Completed")}
+	if err := ss.conn.AddMatchSignalContext(ctx, opts...); err != nil {
+		return dbus.Variant{}, err
+	}
+	defer ss.conn.RemoveMatchSignal(opts...)
+	signals := make(chan *dbus.Signal, 1)
+	ss.conn.Signal(signals)
+	defer ss.conn.RemoveSignal(signals)
+
+	if err := ss.conn.Object(ssDest, path).CallWithContext(ctx, ssIface+"Prompt.Prompt", 0, "").Err; err != nil {
+		return dbus.Variant{}, err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return dbus.Variant{}, ctx.Err()
+		case s := <-signals:
+			if s.Path != path || s.Name != ssIface+"Prompt.Completed" || len(s.Body) != 2 {
+				continue
+			}
+			if dismissed, _ := s.Body[0].(bool); dismissed {
+				return dbus.Variant{}, errors.New("prompt dismissed")
+			}
+			result, _ := s.Body[1].(dbus.Variant)
+			return result, nil
+		}
+	}
+}
+
+// search returns the items matching the store attributes, and the key when not empty.
+func (ss *SecretServiceStore) search(ctx context.Context, k string) ([]dbus.ObjectPath, error) {
+	attrs := ss.itemAttributes(k)
+	if k == "" {
+		delete(attrs, "username")
+	}
+	var items []dbus.ObjectPath
+	if err := ss.conn.Object(ssDest, ss.collection).CallWithContext(ctx, ssIface+"Collection.SearchItems", 0, attrs).Store(&items); err != nil {
+		return nil, fmt.Errorf("search items: %w", err)
+	}
+	return items, nil
+}
+
+func (ss *SecretServiceStore) itemAttributes(k string) map[string]string {
+	attrs := make(map[string]string, len(ss.attributes)+1)
+	for a, v := range ss.attributes {
+		attrs[a] = v
+	}
+	attrs["username"] = k
+	return attrs
+}
+
+// Close closes the D-Bus connection.
+func (ss *SecretServiceStore) Close() error {
+	return ss.conn.Close()
+}
+
+// Get implements the Store.Get method.
+func (ss *SecretServiceStore) Get(ctx context.Context, k string) (string, error) {
+	items, err := ss.search(ctx, k)
+	if err != nil {
+		return "", err
+	}
+	if len(items) == 0 {
+		return "", ErrKeyNotFound
+	}
+	var secret ssSecret
+	if err := ss.conn.Object(ssDest, items[0]).CallWithContext(ctx, ssIface+"Item.GetSecret", 0, ss.session).Store(&secret); err != nil {
+		return "", fmt.Errorf("get secret: %w", err)
+	}
+	return string(secret.Value), nil
+}
+
+// Set implements the Store.Set method.
+func (ss *SecretServiceStore) Set(ctx context.Context, k, v string) error {
+	props := map[string]dbus.Variant{
+		ssIface + "Item.Label":      dbus.MakeVariant(strings.ReplaceAll(ss.label, "{key}", k)),
+		ssIface + "Item.Attributes": dbus.MakeVariant(ss.itemAttributes(k)),
+	}
+	secret := ssSecret{Session: ss.session, Value: []byte(v), ContentType: "text/plain; charset=utf8"}
+	var item, prompt dbus.ObjectPath
+	if err := ss.conn.Object(ssDest, ss.collection).CallWithContext(ctx, ssIface+"Collection.CreateItem", 0, props, secret, true).Store(&item, &prompt); err != nil {
+		return fmt.Errorf("create item: %w", err)
+	}
+	_, err := ss.prompt(ctx, prompt)
+	return err
+}
+
+// Delete implements the Store.Delete method.
+func (ss *SecretServiceStore) Delete(ctx context.Context, k string) error {
+	items, err := ss.search(ctx, k)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		var prompt dbus.ObjectPath
+		if err := ss.conn.Object(ssDest, item).CallWithContext(ctx, ssIface+"Item.Delete", 0).Store(&prompt); err != nil {
+			return fmt.Errorf("delete item: %w", err)
+		}
+		if _, err := ss.prompt(ctx, prompt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List implements the Lister.List method.
+func (ss *SecretServiceStore) List(ctx context.Context, prefix string) ([]string, error) {
+	items, err := ss.search(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, item := range items {
+		v, err := ss.conn.Object(ssDest, item).GetProperty(ssIface + "Item.Attributes")
+		if err != nil {
+			return nil, fmt.Errorf("read item attributes: %w", err)
+		}
+		attrs, _ := v.Value().(map[string]string)
+		if k, ok := attrs["username"]; ok && strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
@@ -0,0 +1,359 @@
+//go:build linux
+
+package backend
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSecretService is an in-memory Secret Service, served on a private session bus.
+// Collection creation and unlocking go through prompts, which are dismissed when dismiss is set.
+type fakeSecretService struct {
+	mu          sync.Mutex
+	conn        *dbus.Conn
+	collections map[dbus.ObjectPath]*fakeCollection
+	aliases     map[string]dbus.ObjectPath
+	next        int
+	dismiss     bool
+}
+
+type fakeCollection struct {
+	ss     *fakeSecretService
+	path   dbus.ObjectPath
+	label  string
+	locked bool
+	items  map[dbus.ObjectPath]*fakeItem
+}
+
+type fakeItem struct {
+	c      *fakeCollection
+	path   dbus.ObjectPath
+	label  string
+	attrs  map[string]string
+	secret []byte
+}
+
+type fakePrompt struct {
+	ss     *fakeSecretService
+	path   dbus.ObjectPath
+	action func() dbus.Variant
+}
+
+// fakeProps serves the org.freedesktop.DBus.Properties interface of an object.
+type fakeProps struct {
+	ss    *fakeSecretService
+	props func() map[string]any
+}
+
+// startSessionBus runs a private dbus-daemon, and returns its address.
+func startSessionBus(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("dbus-daemon"); err != nil {
+		t.Skip("dbus-daemon is not installed")
+	}
+	cmd := exec.Command("dbus-daemon", "--session", "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	addr, err := bufio.NewReader(stdout).ReadString('\n')
+	require.NoError(t, err)
+	return strings.TrimSpace(addr)
+}
+
+func newFakeSecretService(t *testing.T) (*fakeSecretService, string) {
+	t.Helper()
+	addr := startSessionBus(t)
+	conn, err := dbus.Connect(addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	fs := &fakeSecretService{conn: conn, collections: map[dbus.ObjectPath]*fakeCollection{}, aliases: map[string]dbus.ObjectPath{}}
+	require.NoError(t, conn.Export(fs, ssPath, ssIface+"Service"))
+	require.NoError(t, conn.Export(&fakeProps{fs, func() map[string]any {
+		paths := []dbus.ObjectPath{}
+		for p := range fs.collections {
+			paths = append(paths, p)
+		}
+		return map[string]any{ssIface + "Service.Collections": paths}
+	}}, ssPath, "org.freedesktop.DBus.Properties"))
+	reply, err := conn.RequestName(ssDest, dbus.NameFlagDoNotQueue)
+	require.NoError(t, err)
+	require.Equal(t, dbus.RequestNameReplyPrimaryOwner, reply)
+	return fs, addr
+}
+
+// locked runs f holding the service lock, to inspect or alter its state from tests.
+func (fs *fakeSecretService) locked(f func()) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	f()
+}
+
+func (fs *fakeSecretService) path(kind string) dbus.ObjectPath {
+	fs.next++
+	return dbus.ObjectPath(fmt.Sprintf("%s/%s/%d", ssPath, kind, fs.next))
+}
+
+func (fs *fakeSecretService) addCollection(label string, locked bool) *fakeCollection {
+	c := &fakeCollection{ss: fs, path: fs.path("collection"), label: label, locked: locked, items: map[dbus.ObjectPath]*fakeItem{}}
+	fs.collections[c.path] = c
+	fs.conn.Export(c, c.path, ssIface+"Collection")
+	fs.conn.Export(&fakeProps{fs, func() map[string]any {
+		return map[string]any{ssIface + "Collection.Label": c.label, ssIface + "Collection.Locked": c.locked}
+	}}, c.path, "org.freedesktop.DBus.Properties")
+	return c
+}
+
+func (fs *fakeSecretService) newPrompt(action func() dbus.Variant) dbus.ObjectPath {
+	p := &fakePrompt{ss: fs, path: fs.path("prompt"), action: action}
+	fs.conn.Export(p, p.path, ssIface+"Prompt")
+	return p.path
+}
+
+func (fs *fakeSecretService) OpenSession(algorithm string, input dbus.Variant) (dbus.Variant, dbus.ObjectPath, *dbus.Error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if algorithm != "plain" {
+		return dbus.Variant{}, "", dbus.NewError("org.freedesktop.DBus.Error.NotSupported", nil)
+	}
+	return dbus.MakeVariant(""), fs.path("session"), nil
+}
+
+func (fs *fakeSecretService) ReadAlias(name string) (dbus.ObjectPath, *dbus.Error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if p, ok := fs.aliases[name]; ok {
+		return p, nil
+	}
+	return ssNoPrompt, nil
+}
+
+func (fs *fakeSecretService) CreateCollection(props map[string]dbus.Variant, alias string) (dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	label, _ := props[ssIface+"Collection.Label"].Value().(string)
+	return ssNoPrompt, fs.newPrompt(func() dbus.Variant {
+		c := fs.addCollection(label, false)
+		if alias != "" {
+			fs.aliases[alias] = c.path
+		}
+		return dbus.MakeVariant(c.path)
+	}), nil
+}
+
+func (fs *fakeSecretService) Unlock(objects []dbus.ObjectPath) ([]dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return []dbus.ObjectPath{}, fs.newPrompt(func() dbus.Variant {
+		for _, p := range objects {
+			fs.collections[p].locked = false
+		}
+		return dbus.MakeVariant(objects)
+	}), nil
+}
+
+func (p *fakePrompt) Prompt(windowID string) *dbus.Error {
+	p.ss.mu.Lock()
+	defer p.ss.mu.Unlock()
+	result := dbus.MakeVariant("")
+	if !p.ss.dismiss {
+		result = p.action()
+	}
+	p.ss.conn.Emit(p.path, ssIface+"Prompt.Completed", p.ss.dismiss, result)
+	return nil
+}
+
+func (c *fakeCollection) SearchItems(attrs map[string]string) ([]dbus.ObjectPath, *dbus.Error) {
+	c.ss.mu.Lock()
+	defer c.ss.mu.Unlock()
+	results := []dbus.ObjectPath{}
+	for p, it := range c.items {
+		match := true
+		for k, v := range attrs {
+			match = match && it.attrs[k] == v
+		}
+		if match {
+			results = append(results, p)
+		}
+	}
+	return results, nil
+}
+
+func (c *fakeCollection) CreateItem(props map[string]dbus.Variant, secret ssSecret, replace bool) (dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	c.ss.mu.Lock()
+	defer c.ss.mu.Unlock()
+	if c.locked {
+		return "", "", dbus.NewError(ssIface+"Error.IsLocked", nil)
+	}
+	label, _ := props[ssIface+"Item.Label"].Value().(string)
+	attrs, _ := props[ssIface+"Item.Attributes"].Value().(map[string]string)
+	for _, it := range c.items {
+		if replace && fmt.Sprint(it.attrs) == fmt.Sprint(attrs) {
+			it.label, it.secret = label, secret.Value
+			return it.path, ssNoPrompt, nil
+		}
+	}
+	it := &fakeItem{c: c, path: c.ss.path("item"), label: label, attrs: attrs, secret: secret.Value}
+	c.items[it.path] = it
+	c.ss.conn.Export(it, it.path, ssIface+"Item")
+	c.ss.conn.Export(&fakeProps{c.ss, func() map[string]any {
+		return map[string]any{ssIface + "Item.Label": it.label, ssIface + "Item.Attributes": it.attrs}
+	}}, it.path, "org.freedesktop.DBus.Properties")
+	return it.path, ssNoPrompt, nil
+}
+
+func (it *fakeItem) GetSecret(session dbus.ObjectPath) (ssSecret, *dbus.Error) {
+	it.c.ss.mu.Lock()
+	defer it.c.ss.mu.Unlock()
+	if it.c.locked {
+		return ssSecret{}, dbus.NewError(ssIface+"Error.IsLocked", nil)
+	}
+	return ssSecret{Session: session, Parameters: []byte{}, Value: it.secret, ContentType: "text/plain"}, nil
+}
+
+func (it *fakeItem) Delete() (dbus.ObjectPath, *dbus.Error) {
+	it.c.ss.mu.Lock()
+	defer it.c.ss.mu.Unlock()
+	delete(it.c.items, it.path)
+	it.c.ss.conn.Export(nil, it.path, ssIface+"Item")
+	return ssNoPrompt, nil
+}
+
+func (fp *fakeProps) Get(iface, name string) (dbus.Variant, *dbus.Error) {
+	fp.ss.mu.Lock()
+	defer fp.ss.mu.Unlock()
+	v, ok := fp.props()[iface+"."+name]
+	if !ok {
+		return dbus.Variant{}, dbus.NewError("org.freedesktop.DBus.Error.UnknownProperty", nil)
+	}
+	return dbus.MakeVariant(v), nil
+}
+
+func newTestSecretServiceStore(t *testing.T, addr, collection string) (*SecretServiceStore, error) {
+	t.Helper()
+	conn, err := dbus.Connect(addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return NewSecretServiceStore(context.TODO(), conn, collection, "test", "", map[string]string{"team": "platform"})
+}
+
+func TestSecretServiceStoreBuilder_Build(t *testing.T) {
+	fs, addr := newFakeSecretService(t)
+	var login dbus.ObjectPath
+	fs.locked(func() {
+		login = fs.addCollection("Login", false).path
+		fs.aliases["default"] = login
+	})
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", addr)
+
+	t.Run("default collection", func(t *testing.T) {
+		s, err := (&SecretServiceStoreBuilder{}).Build(context.TODO(), "dev")
+		require.NoError(t, err)
+		ss := s.(*SecretServiceStore)
+		defer ss.Close()
+		assert.Equal(t, login, ss.collection)
+		assert.Equal(t, map[string]string{"service": "clef:dev"}, ss.attributes)
+	})
+
+	t.Run("reserved namespace", func(t *testing.T) {
+		_, err := (&SecretServiceStoreBuilder{}).Build(context.TODO(), "system")
+		assert.ErrorIs(t, err, ErrReservedStoreName)
+	})
+}
+
+func TestSecretServiceStore_Collection(t *testing.T) {
+	fs, addr := newFakeSecretService(t)
+	var work *fakeCollection
+	fs.locked(func() { work = fs.addCollection("work", true) })
+
+	t.Run("by label, unlocked", func(t *testing.T) {
+		ss, err := newTestSecretServiceStore(t, addr, "work")
+		require.NoError(t, err)
+		assert.Equal(t, work.path, ss.collection)
+		fs.locked(func() { assert.False(t, work.locked) })
+	})
+
+	t.Run("created", func(t *testing.T) {
+		ss, err := newTestSecretServiceStore(t, addr, "clef")
+		require.NoError(t, err)
+		fs.locked(func() {
+			if assert.Contains(t, fs.collections, ss.collection) {
+				assert.Equal(t, "clef", fs.collections[ss.collection].label)
+			}
+		})
+	})
+
+	t.Run("default created with alias", func(t *testing.T) {
+		ss, err := newTestSecretServiceStore(t, addr, "")
+		require.NoError(t, err)
+		fs.locked(func() { assert.Equal(t, fs.aliases["default"], ss.collection) })
+	})
+
+	t.Run("unlock dismissed", func(t *testing.T) {
+		fs.locked(func() {
+			fs.dismiss = true
+			fs.addCollection("locked", true)
+		})
+		defer fs.locked(func() { fs.dismiss = false })
+		_, err := newTestSecretServiceStore(t, addr, "locked")
+		assert.EqualError(t, err, "unlock collection locked: prompt dismissed")
+	})
+}
+
+func TestSecretServiceStore(t *testing.T) {
+	fs, addr := newFakeSecretService(t)
+	ss, err := newTestSecretServiceStore(t, addr, "work")
+	require.NoError(t, err)
+	var collection *fakeCollection
+	fs.locked(func() { collection = fs.collections[ss.collection] })
+	ctx := context.TODO()
+
+	t.Run("get not found", func(t *testing.T) {
+		_, err := ss.Get(ctx, "nokey")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("set and get", func(t *testing.T) {
+		require.NoError(t, ss.Set(ctx, "db", "hunter2"))
+		require.NoError(t, ss.Set(ctx, "db", "hunter3"))
+		fs.locked(func() {
+			require.Len(t, collection.items, 1)
+			for _, it := range collection.items {
+				assert.Equal(t, "Password for 'db' on 'clef:test'", it.label)
+				assert.Equal(t, map[string]string{"service": "clef:test", "username": "db", "team": "platform"}, it.attrs)
+			}
+		})
+
+		v, err := ss.Get(ctx, "db")
+		require.NoError(t, err)
+		assert.Equal(t, "hunter3", v)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		require.NoError(t, ss.Set(ctx, "api", "x"))
+		keys, err := ss.List(ctx, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"api", "db"}, keys)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, ss.Delete(ctx, "api"))
+		require.NoError(t, ss.Delete(ctx, "api"))
+		_, err := ss.Get(ctx, "api")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+}
@@ -0,0 +1,248 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	registerBuilder("sops", func() Builder { return new(SopsStoreBuilder) })
+}
+
+// SopsStoreBuilder implements the Builder interface for SopsStore.
+type SopsStoreBuilder struct {
+	// Path is the SOPS encrypted file. Its format is inferred from the extension.
+	Path string `toml:"path"`
+	// AgeKeyFile is the age identity file, defaults to SOPS_AGE_KEY_FILE or the sops default location.
+	AgeKeyFile string `toml:"age-key-file,omitempty"`
+	// Sops is the sops executable, defaults to "sops".
+	Sops string `toml:"sops,omitempty"`
+}
+
+// Build returns a new SopsStore store.
+func (sb *SopsStoreBuilder) Build(ctx context.Context, name string) (Store, error) {
+	if sb.Path == "" {
+		return nil, errors.New("sops store requires a path")
+	}
+	var env []string
+	if sb.AgeKeyFile != "" {
+		env = append(env, "SOPS_AGE_KEY_FILE="+sb.AgeKeyFile)
+	}
+	return NewSopsStore(sb.Path, firstNonEmpty(sb.Sops, "sops"), env...)
+}
+
+// SopsStore reads and writes a [SOPS] encrypted YAML, JSON, dotenv or INI file, through the sops CLI.
+// The file is decrypted with any key sops can use (age, PGP or a cloud KMS).
+//
+// Keys are paths in the document, with segments separated by dots, e.g. database.password, or by
+// slashes, e.g. database/password, which fits secret references where dots separate the store from
+// the key. Keys with a slash are slash separated, and a leading slash reads a top-level key with dots.
+// Numeric segments are array indices. Values that are not strings are returned as JSON.
+// Writes go through `sops set` and `sops unset`, which keep the file data key, so changes stay
+// reviewable in git. They require sops 3.10 or later. The file must already exist.
+//
+// [SOPS]: https://getsops.io
+type SopsStore struct {
+	path string
+	sops string
+	env  []string
+}
+
+// NewSopsStore creates a new SopsStore for the encrypted file at path, using the sops executable.
+// env is added to the sops environment.
+func NewSopsStore(path, sops string, env ...string) (*SopsStore, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("open sops file: %w", err)
+	}
+	return &SopsStore{path, sops, env}, nil
+}
+
+func (s *SopsStore) run(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.sops, args...)
+	cmd.Env = append(os.Environ(), s.env...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("sops %s: %s", args[0], msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+// decrypt returns the decrypted document.
+func (s *SopsStore) decrypt(ctx context.Context) (any, error) {
+	out, err := s.run(ctx, nil, "--decrypt", "--output-type", "json", s.path)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(out))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode sops document: %w", err)
+	}
+	return doc, nil
+}
+
+// sopsSegments splits the key k in document path segments.
+func sopsSegments(k string) []string {
+	if strings.Contains(k, "/") {
+		return strings.Split(strings.TrimPrefix(k, "/"), "/")
+	}
+	return strings.Split(k, ".")
+}
+
+// lookupSopsKey returns the node at key k in doc, or ErrKeyNotFound.
+func lookupSopsKey(doc any, k string) (any, error) {
+	node := doc
+	for _, seg := range sopsSegments(k) {
+		switch n := node.(type) {
+		case map[string]any:
+			v, ok := n[seg]
+			if !ok {
+				return nil, ErrKeyNotFound
+			}
+			node = v
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, ErrKeyNotFound
+			}
+			node = n[i]
+		default:
+			return nil, ErrKeyNotFound
+		}
+	}
+	return node, nil
+}
+
+// sopsPath converts the key k to a sops tree path, like ["database"]["password"].
+func sopsPath(k string) (string, error) {
+	var b strings.Builder
+	for _, seg := range sopsSegments(k) {
+		if seg == "" {
+			return "", fmt.Errorf("invalid key %s", k)
+		}
+		if _, err := strconv.Atoi(seg); err == nil {
+			fmt.Fprintf(&b, "[%s]", seg)
+			continue
+		}
+		q, err := json.Marshal(seg)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "[%s]", q)
+	}
+	return b.String(), nil
+}
+
+// Get implements the Store.Get method.
+func (s *SopsStore) Get(ctx context.Context, k string) (string, error) {
+	doc, err := s.decrypt(ctx)
+	if err != nil {
+		return "", err
+	}
+	node, err := lookupSopsKey(doc, k)
+	if err != nil {
+		return "", err
+	}
+	switch v := node.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case nil:
+		return "", nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+}
+
+// Set implements the Store.Set method.
+func (s *SopsStore) Set(ctx context.Context, k, v string) error {
+	path, err := sopsPath(k)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	// The value is read from stdin, keeping it out of the process list
+	_, err = s.run(ctx, value, "set", "--value-stdin", s.path, path)
+	return err
+}
+
+// Delete implements the Store.Delete method.
+func (s *SopsStore) Delete(ctx context.Context, k string) error {
+	path, err := sopsPath(k)
+	if err != nil {
+		return err
+	}
+	doc, err := s.decrypt(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := lookupSopsKey(doc, k); errors.Is(err, ErrKeyNotFound) {
+		return nil
+	}
+	_, err = s.run(ctx, nil, "unset", s.path, path)
+	return err
+}
+
+// List implements the Lister.List method.
+// It lists the slash separated paths of the document leaves.
+func (s *SopsStore) List(ctx context.Context, prefix string) ([]string, error) {
+	doc, err := s.decrypt(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	var walk func(string, any)
+	walk = func(k string, node any) {
+		join := func(seg string) string {
+			if k == "" {
+				return seg
+			}
+			return k + "/" + seg
+		}
+		switch n := node.(type) {
+		case map[string]any:
+			for seg, v := range n {
+				walk(join(seg), v)
+			}
+		case []any:
+			for i, v := range n {
+				walk(join(strconv.Itoa(i)), v)
+			}
+		default:
+			if !strings.Contains(k, "/") && strings.Contains(k, ".") {
+				// A top-level key with dots
+				k = "/" + k
+			}
+			if k != "" && strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+	}
+	walk("", doc)
+	slices.Sort(keys)
+	return keys, nil
+}
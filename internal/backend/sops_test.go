@@ -0,0 +1,134 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSopsDoc = `{
+  "database": {"user": "app", "password": "hunter2", "port": 5432},
+  "servers": [{"host": "a.example.com"}, {"host": "b.example.com"}],
+  "tls": {"enabled": true, "ca": null},
+  "smtp.example.com": "relay"
+}`
+
+// fakeSops writes a sops executable decrypting to testSopsDoc.
+// The arguments and stdin of set and unset calls are saved to the returned file.
+func fakeSops(t *testing.T) (*SopsStore, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake sops CLI requires a POSIX shell")
+	}
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "plain.json")
+	written := filepath.Join(dir, "written")
+	file := filepath.Join(dir, "secrets.enc.yaml")
+	require.NoError(t, os.WriteFile(plain, []byte(testSopsDoc), 0600))
+	require.NoError(t, os.WriteFile(file, []byte("sealed"), 0600))
+	script := fmt.Sprintf(`#!/bin/sh
+[ "$SOPS_AGE_KEY_FILE" = key.txt ] || { echo "Failed to get the data key required to decrypt the SOPS file." >&2; exit 128; }
+case "$1" in
+--decrypt) cat %s;;
+set|unset) echo "$@" > %s; cat >> %s;;
+esac
+`, plain, written, written)
+	bin := filepath.Join(dir, "sops")
+	require.NoError(t, os.WriteFile(bin, []byte(script), 0o755))
+	store, err := NewSopsStore(file, bin, "SOPS_AGE_KEY_FILE=key.txt")
+	require.NoError(t, err)
+	return store, written
+}
+
+func TestNewSopsStore(t *testing.T) {
+	_, err := NewSopsStore(filepath.Join(t.TempDir(), "missing.yaml"), "sops")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestSopsStore_Get(t *testing.T) {
+	store, _ := fakeSops(t)
+	ctx := context.TODO()
+
+	tcs := map[string]string{
+		"database/password": "hunter2",
+		"database/port":     "5432",
+		"servers/1/host":    "b.example.com",
+		"tls/enabled":       "true",
+		"tls/ca":            "",
+		"servers/0":         `{"host":"a.example.com"}`,
+		"database.password": "hunter2",
+		"servers.1.host":    "b.example.com",
+		"/smtp.example.com": "relay",
+	}
+	for k, expected := range tcs {
+		v, err := store.Get(ctx, k)
+		if assert.NoError(t, err, k) {
+			assert.Equal(t, expected, v, k)
+		}
+	}
+
+	for _, k := range []string{"database/host", "servers/2/host", "servers/x", "database/user/name", "database.host", "smtp.example.com"} {
+		_, err := store.Get(ctx, k)
+		assert.ErrorIs(t, err, ErrKeyNotFound, k)
+	}
+
+	locked := &SopsStore{path: store.path, sops: store.sops}
+	_, err := locked.Get(ctx, "database/password")
+	assert.EqualError(t, err, "sops --decrypt: Failed to get the data key required to decrypt the SOPS file.")
+}
+
+func TestSopsStore_Set(t *testing.T) {
+	store, written := fakeSops(t)
+	require.NoError(t, store.Set(context.TODO(), "database/password", `new"pass`))
+	b, err := os.ReadFile(written)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("set --value-stdin %s [\"database\"][\"password\"]\n\"new\\\"pass\"", store.path), string(b))
+
+	require.NoError(t, store.Set(context.TODO(), "servers/0/host", "c.example.com"))
+	b, err = os.ReadFile(written)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `["servers"][0]["host"]`)
+
+	require.NoError(t, store.Set(context.TODO(), "app.example.com/token", "x"))
+	b, err = os.ReadFile(written)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `["app.example.com"]["token"]`, "dots are part of segments")
+
+	require.NoError(t, store.Set(context.TODO(), "database.user", "x"))
+	b, err = os.ReadFile(written)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `["database"]["user"]`)
+
+	assert.EqualError(t, store.Set(context.TODO(), "database//password", "x"), "invalid key database//password")
+	assert.EqualError(t, store.Set(context.TODO(), "database..password", "x"), "invalid key database..password")
+}
+
+func TestSopsStore_Delete(t *testing.T) {
+	store, written := fakeSops(t)
+	require.NoError(t, store.Delete(context.TODO(), "database/user"))
+	b, err := os.ReadFile(written)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("unset %s [\"database\"][\"user\"]\n", store.path), string(b))
+
+	require.NoError(t, os.Remove(written))
+	require.NoError(t, store.Delete(context.TODO(), "database/host"))
+	assert.NoFileExists(t, written)
+}
+
+func TestSopsStore_List(t *testing.T) {
+	store, _ := fakeSops(t)
+
+	keys, err := store.List(context.TODO(), "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/smtp.example.com", "database/password", "database/port", "database/user", "servers/0/host", "servers/1/host", "tls/ca", "tls/enabled"}, keys)
+
+	keys, err = store.List(context.TODO(), "servers/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"servers/0/host", "servers/1/host"}, keys)
+}
@@ -0,0 +1,10 @@
+//go:build unix
+
+package backend
+
+import "os"
+
+// openTTY opens the controlling terminal, which stays readable when stdin is piped.
+func openTTY() (*os.File, error) {
+	return os.OpenFile("/dev/tty", os.O_RDWR, 0)
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package backend
+
+import "os"
+
+// openTTY opens the console input, which stays readable when stdin is piped.
+func openTTY() (*os.File, error) {
+	return os.OpenFile("CONIN$", os.O_RDWR, 0)
+}
@@ -0,0 +1,362 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+func init() {
+	registerBuilder("vault", func() Builder { return new(VaultStoreBuilder) })
+}
+
+var errVaultNotFound = errors.New("vault path not found")
+
+// VaultStoreBuilder implements the Builder interface for HashiCorp Vault KV v2 secrets engine.
+type VaultStoreBuilder struct {
+	// Address of the Vault server, defaults to VAULT_ADDR.
+	Address string `toml:"address"`
+	// Namespace is the Vault Enterprise namespace, defaults to VAULT_NAMESPACE.
+	Namespace string `toml:"namespace,omitempty"`
+	// Mount is the KV v2 secrets engine mount path, defaults to "secret".
+	Mount string `toml:"mount,omitempty"`
+	// Prefix is prepended to every key path.
+	Prefix string `toml:"prefix,omitempty"`
+	// Field is the secret data field holding the value, defaults to "value".
+	Field string `toml:"field,omitempty"`
+	// Auth configures how to authenticate against Vault.
+	Auth VaultAuth `toml:"auth"`
+}
+
+// VaultAuth configures the Vault authentication method.
+type VaultAuth struct {
+	// Method is one of token (default), approle or userpass.
+	// The token method reads VAULT_TOKEN, or the token helper file written by `vault login`.
+	Method string `toml:"method,omitempty"`
+	// Mount is the auth method mount path, defaults to the method name.
+	Mount string `toml:"mount,omitempty"`
+	// RoleID is the AppRole role ID. The secret ID is read from VAULT_SECRET_ID.
+	RoleID string `toml:"role-id,omitempty"`
+	// Username is the userpass username. The password is read from VAULT_PASSWORD, or prompted.
+	Username string `toml:"username,omitempty"`
+	// CacheToken caches the token obtained by approle or userpass login in the system store.
+	CacheToken bool `toml:"cache-token,omitempty"`
+}
+
+// Build returns a new VaultStore store.
+func (vb *VaultStoreBuilder) Build(ctx context.Context, name string) (Store, error) {
+	client := &vaultClient{
+		http:      http.DefaultClient,
+		address:   strings.TrimSuffix(firstNonEmpty(vb.Address, os.Getenv("VAULT_ADDR")), "/"),
+		namespace: firstNonEmpty(vb.Namespace, os.Getenv("VAULT_NAMESPACE")),
+	}
+	if client.address == "" {
+		return nil, fmt.Errorf("missing address")
+	}
+	if err := vb.Auth.login(ctx, client, name); err != nil {
+		return nil, fmt.Errorf("vault login: %w", err)
+	}
+
+	return &VaultStore{
+		client: client,
+		mount:  firstNonEmpty(vb.Mount, "secret"),
+		prefix: vb.Prefix,
+		field:  firstNonEmpty(vb.Field, "value"),
+	}, nil
+}
+
+func (va *VaultAuth) login(ctx context.Context, c *vaultClient, name string) error {
+	method := firstNonEmpty(va.Method, "token")
+	mount := firstNonEmpty(va.Mount, method)
+	cacheKey := "vault:" + name
+
+	if method == "token" {
+		token, err := vaultToken()
+		c.token = token
+		return err
+	}
+
+	if va.CacheToken {
+		if token, err := SystemStore.Get(ctx, cacheKey); err == nil {
+			c.token = token
+			if err := c.do(ctx, http.MethodGet, "auth/token/lookup-self", nil, nil); err == nil {
+				return nil
+			}
+			c.token = ""
+		}
+	}
+
+	var err error
+	switch method {
+	case "approle":
+		c.token, err = c.login(ctx, path.Join("auth", mount, "login"), map[string]string{
+			"role_id":   va.RoleID,
+			"secret_id": os.Getenv("VAULT_SECRET_ID"),
+		})
+	case "userpass":
+		password := os.Getenv("VAULT_PASSWORD")
+		if password == "" {
+			password, err = promptSecret(fmt.Sprintf("Vault password for %s: ", va.Username))
+			if err != nil {
+				return fmt.Errorf("read password: %w (set VAULT_PASSWORD)", err)
+			}
+		}
+		c.token, err = c.login(ctx, path.Join("auth", mount, "login", va.Username), map[string]string{
+			"password": password,
+		})
+	default:
+		return fmt.Errorf("unsupported auth method '%s'", method)
+	}
+	if err != nil {
+		return err
+	}
+
+	if va.CacheToken {
+		if err := SystemStore.Set(ctx, cacheKey, c.token); err != nil {
+			return fmt.Errorf("cache token: %w", err)
+		}
+	}
+	return nil
+}
+
+func vaultToken() (string, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	token, err := os.ReadFile(path.Join(home, ".vault-token"))
+	if err != nil {
+		return "", fmt.Errorf("no token found: set VAULT_TOKEN or run vault login")
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// vaultClient is a minimal client of the Vault HTTP API.
+type vaultClient struct {
+	http      *http.Client
+	address   string
+	namespace string
+	token     string
+}
+
+func (c *vaultClient) login(ctx context.Context, p string, payload map[string]string) (string, error) {
+	var res struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if err := c.do(ctx, http.MethodPost, p, payload, &res); err != nil {
+		return "", err
+	}
+	if res.Auth.ClientToken == "" {
+		return "", fmt.Errorf("no token returned by %s", p)
+	}
+	return res.Auth.ClientToken, nil
+}
+
+// do sends a request to the API path p, with in JSON encoded as body, and decodes the response into out.
+// It returns errVaultNotFound on 404 responses.
+func (c *vaultClient) do(ctx context.Context, method, p string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	u := c.address + "/v1/" + (&url.URL{Path: p}).EscapedPath()
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("X-Vault-Token", c.token)
+	}
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return errVaultNotFound
+	}
+	if res.StatusCode >= 400 {
+		var verr struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(res.Body).Decode(&verr)
+		return fmt.Errorf("%s %s: %s: %s", method, p, res.Status, strings.Join(verr.Errors, ", "))
+	}
+	if out == nil || res.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// VaultStore uses a HashiCorp Vault KV v2 secrets engine to store secrets.
+// Each key is a secret path, and its value is held in a single field of the secret data.
+type VaultStore struct {
+	client *vaultClient
+	mount  string
+	prefix string
+	field  string
+}
+
+type vaultSecret struct {
+	Data struct {
+		Data map[string]any `json:"data"`
+	} `json:"data"`
+}
+
+// path returns the API path of key, rejecting keys that would escape the mount and prefix once joined.
+func (v *VaultStore) path(kind, key string) (string, error) {
+	if key == "" || path.IsAbs(key) || slices.Contains(strings.Split(key, "/"), "..") {
+		return "", fmt.Errorf("invalid vault key '%s': must be a relative path without '..'", key)
+	}
+	return v.join(kind, key), nil
+}
+
+func (v *VaultStore) join(kind, key string) string {
+	return path.Join(v.mount, kind, v.prefix, key)
+}
+
+func (v *VaultStore) read(ctx context.Context, k string) (map[string]any, error) {
+	p, err := v.path("data", k)
+	if err != nil {
+		return nil, err
+	}
+	var secret vaultSecret
+	if err := v.client.do(ctx, http.MethodGet, p, nil, &secret); err != nil {
+		if errors.Is(err, errVaultNotFound) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, fmt.Errorf("read vault secret: %w", err)
+	}
+	return secret.Data.Data, nil
+}
+
+// Get implements the Store.Get method.
+func (v *VaultStore) Get(ctx context.Context, k string) (string, error) {
+	data, err := v.read(ctx, k)
+	if err != nil {
+		return "", err
+	}
+	value, ok := data[v.field]
+	if !ok {
+		return "", fmt.Errorf("field %s: %w", v.field, ErrKeyNotFound)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("field %s is not a string", v.field)
+	}
+	return s, nil
+}
+
+// Set implements the Store.Set method.
+// Other fields of an existing secret are preserved.
+func (v *VaultStore) Set(ctx context.Context, k, value string) error {
+	data, err := v.read(ctx, k)
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		return err
+	}
+	if data == nil {
+		data = make(map[string]any)
+	}
+	data[v.field] = value
+
+	payload := map[string]any{"data": data}
+	p, err := v.path("data", k)
+	if err != nil {
+		return err
+	}
+	if err := v.client.do(ctx, http.MethodPost, p, payload, nil); err != nil {
+		return fmt.Errorf("write vault secret: %w", err)
+	}
+	return nil
+}
+
+// Delete implements the Store.Delete method.
+// It deletes the secret metadata, and with it every version of the secret.
+func (v *VaultStore) Delete(ctx context.Context, k string) error {
+	p, err := v.path("metadata", k)
+	if err != nil {
+		return err
+	}
+	err = v.client.do(ctx, http.MethodDelete, p, nil, nil)
+	if err != nil && !errors.Is(err, errVaultNotFound) {
+		return fmt.Errorf("delete vault secret: %w", err)
+	}
+	return nil
+}
+
+// List implements the Lister.List method.
+func (v *VaultStore) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		var res struct {
+			Data struct {
+				Keys []string `json:"keys"`
+			} `json:"data"`
+		}
+		err := v.client.do(ctx, "LIST", v.join("metadata", dir), nil, &res)
+		if errors.Is(err, errVaultNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("list vault secrets: %w", err)
+		}
+		for _, k := range res.Data.Keys {
+			full := dir + k
+			if strings.HasSuffix(k, "/") {
+				// Skip folders that cannot hold a matching key
+				if !strings.HasPrefix(full, prefix) && !strings.HasPrefix(prefix, full) {
+					continue
+				}
+				if err := walk(full); err != nil {
+					return err
+				}
+			} else if strings.HasPrefix(full, prefix) {
+				keys = append(keys, full)
+			}
+		}
+		return nil
+	}
+
+	if err := walk(""); err != nil {
+		return nil, err
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
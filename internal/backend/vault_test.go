@@ -0,0 +1,271 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+// fakeVault is an in-memory fake of the Vault HTTP API, serving a KV v2 engine mounted at "secret",
+// and the approle and userpass auth methods.
+type fakeVault struct {
+	mu        sync.Mutex
+	token     string
+	namespace string
+	secrets   map[string]map[string]any
+	logins    int
+}
+
+func newFakeVault(t *testing.T) (*fakeVault, *httptest.Server) {
+	t.Helper()
+	fv := &fakeVault{token: "s.root", secrets: make(map[string]map[string]any)}
+	srv := httptest.NewServer(fv)
+	t.Cleanup(srv.Close)
+	return fv, srv
+}
+
+func (fv *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+
+	reply := func(code int, body any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		if body != nil {
+			json.NewEncoder(w).Encode(body)
+		}
+	}
+	denied := func() { reply(http.StatusForbidden, map[string]any{"errors": []string{"permission denied"}}) }
+
+	p := strings.TrimPrefix(r.URL.Path, "/v1/")
+	var in map[string]any
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&in)
+	}
+
+	switch {
+	case p == "auth/approle/login":
+		if in["role_id"] != "role" || in["secret_id"] != "secret" {
+			denied()
+			return
+		}
+		fv.logins++
+		reply(http.StatusOK, map[string]any{"auth": map[string]any{"client_token": fv.token}})
+		return
+	case strings.HasPrefix(p, "auth/userpass/login/"):
+		if path.Base(p) != "alice" || in["password"] != "wonderland" {
+			denied()
+			return
+		}
+		fv.logins++
+		reply(http.StatusOK, map[string]any{"auth": map[string]any{"client_token": fv.token}})
+		return
+	}
+
+	if r.Header.Get("X-Vault-Token") != fv.token || r.Header.Get("X-Vault-Namespace") != fv.namespace {
+		denied()
+		return
+	}
+
+	switch {
+	case p == "auth/token/lookup-self":
+		reply(http.StatusOK, map[string]any{"data": map[string]any{}})
+	case strings.HasPrefix(p, "secret/data/"):
+		key := strings.TrimPrefix(p, "secret/data/")
+		switch r.Method {
+		case http.MethodGet:
+			data, ok := fv.secrets[key]
+			if !ok {
+				reply(http.StatusNotFound, map[string]any{"errors": []string{}})
+				return
+			}
+			reply(http.StatusOK, map[string]any{"data": map[string]any{"data": data}})
+		case http.MethodPost, http.MethodPut:
+			fv.secrets[key] = in["data"].(map[string]any)
+			reply(http.StatusOK, map[string]any{"data": map[string]any{"version": 1}})
+		}
+	case strings.HasPrefix(p, "secret/metadata"):
+		key := strings.TrimPrefix(strings.TrimPrefix(p, "secret/metadata"), "/")
+		switch r.Method {
+		case http.MethodDelete:
+			delete(fv.secrets, key)
+			reply(http.StatusNoContent, nil)
+		case "LIST":
+			dir := key
+			if dir != "" {
+				dir += "/"
+			}
+			seen := map[string]bool{}
+			var keys []string
+			for k := range fv.secrets {
+				if rest, ok := strings.CutPrefix(k, dir); ok {
+					if i := strings.Index(rest, "/"); i >= 0 {
+						rest = rest[:i+1]
+					}
+					if !seen[rest] {
+						seen[rest] = true
+						keys = append(keys, rest)
+					}
+				}
+			}
+			if len(keys) == 0 {
+				reply(http.StatusNotFound, map[string]any{"errors": []string{}})
+				return
+			}
+			reply(http.StatusOK, map[string]any{"data": map[string]any{"keys": keys}})
+		}
+	default:
+		reply(http.StatusNotFound, map[string]any{"errors": []string{}})
+	}
+}
+
+func TestVaultStoreBuilder_Build(t *testing.T) {
+	t.Run("missing address", func(t *testing.T) {
+		t.Setenv("VAULT_ADDR", "")
+		builder := &VaultStoreBuilder{}
+		s, err := builder.Build(context.TODO(), "test")
+		assert.Nil(t, s)
+		assert.EqualError(t, err, "missing address")
+	})
+
+	t.Run("token from env", func(t *testing.T) {
+		_, srv := newFakeVault(t)
+		t.Setenv("VAULT_ADDR", srv.URL)
+		t.Setenv("VAULT_TOKEN", "s.root")
+
+		builder := &VaultStoreBuilder{}
+		s, err := builder.Build(context.TODO(), "test")
+		if assert.NoError(t, err) {
+			vs := s.(*VaultStore)
+			assert.Equal(t, "s.root", vs.client.token)
+			assert.Equal(t, "secret", vs.mount)
+			assert.Equal(t, "value", vs.field)
+		}
+	})
+
+	t.Run("approle", func(t *testing.T) {
+		_, srv := newFakeVault(t)
+		t.Setenv("VAULT_SECRET_ID", "secret")
+
+		builder := &VaultStoreBuilder{Address: srv.URL, Auth: VaultAuth{Method: "approle", RoleID: "role"}}
+		s, err := builder.Build(context.TODO(), "test")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "s.root", s.(*VaultStore).client.token)
+		}
+	})
+
+	t.Run("approle denied", func(t *testing.T) {
+		_, srv := newFakeVault(t)
+		t.Setenv("VAULT_SECRET_ID", "wrong")
+
+		builder := &VaultStoreBuilder{Address: srv.URL, Auth: VaultAuth{Method: "approle", RoleID: "role"}}
+		_, err := builder.Build(context.TODO(), "test")
+		assert.ErrorContains(t, err, "permission denied")
+	})
+
+	t.Run("userpass with cached token", func(t *testing.T) {
+		keyring.MockInit()
+		fv, srv := newFakeVault(t)
+		t.Setenv("VAULT_PASSWORD", "wonderland")
+
+		builder := VaultStoreBuilder{Address: srv.URL, Auth: VaultAuth{Method: "userpass", Username: "alice", CacheToken: true}}
+		_, err := builder.Build(context.TODO(), "test")
+		require.NoError(t, err)
+		cached, err := SystemStore.Get(context.TODO(), "vault:test")
+		require.NoError(t, err)
+		assert.Equal(t, "s.root", cached)
+
+		// Cached token is reused
+		_, err = builder.Build(context.TODO(), "test")
+		require.NoError(t, err)
+		assert.Equal(t, 1, fv.logins)
+
+		// Stale token triggers a new login
+		fv.token = "s.rotated"
+		s, err := builder.Build(context.TODO(), "test")
+		require.NoError(t, err)
+		assert.Equal(t, 2, fv.logins)
+		assert.Equal(t, "s.rotated", s.(*VaultStore).client.token)
+	})
+
+	t.Run("unsupported method", func(t *testing.T) {
+		builder := &VaultStoreBuilder{Address: "http://vault", Auth: VaultAuth{Method: "kerberos"}}
+		_, err := builder.Build(context.TODO(), "test")
+		assert.EqualError(t, err, "vault login: unsupported auth method 'kerberos'")
+	})
+}
+
+func TestVaultStore(t *testing.T) {
+	fv, srv := newFakeVault(t)
+	fv.namespace = "team"
+	client := &vaultClient{http: srv.Client(), address: srv.URL, namespace: "team", token: "s.root"}
+	store := &VaultStore{client: client, mount: "secret", prefix: "clef", field: "value"}
+	ctx := context.TODO()
+
+	t.Run("get not found", func(t *testing.T) {
+		_, err := store.Get(ctx, "nokey")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("set and get", func(t *testing.T) {
+		require.NoError(t, store.Set(ctx, "db/password", "hunter2"))
+		assert.Equal(t, map[string]any{"value": "hunter2"}, fv.secrets["clef/db/password"])
+
+		v, err := store.Get(ctx, "db/password")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "hunter2", v)
+		}
+	})
+
+	t.Run("set preserves other fields", func(t *testing.T) {
+		fv.secrets["clef/api"] = map[string]any{"user": "bob", "value": "old"}
+		require.NoError(t, store.Set(ctx, "api", "new"))
+		assert.Equal(t, map[string]any{"user": "bob", "value": "new"}, fv.secrets["clef/api"])
+	})
+
+	t.Run("missing field", func(t *testing.T) {
+		fv.secrets["clef/other"] = map[string]any{"password": "x"}
+		_, err := store.Get(ctx, "other")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		keys, err := store.List(ctx, "")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"api", "db/password", "other"}, keys)
+		}
+		keys, err = store.List(ctx, "db/")
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"db/password"}, keys)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, "api"))
+		assert.NotContains(t, fv.secrets, "clef/api")
+	})
+
+	t.Run("keys stay under the prefix", func(t *testing.T) {
+		for _, k := range []string{"", "/secret/data/x", "../../other-mount/data/x", "db/../../x", ".."} {
+			_, err := store.Get(ctx, k)
+			assert.ErrorContains(t, err, "invalid vault key", k)
+			assert.ErrorContains(t, store.Set(ctx, k, "v"), "invalid vault key", k)
+			assert.ErrorContains(t, store.Delete(ctx, k), "invalid vault key", k)
+		}
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		denied := &VaultStore{client: &vaultClient{http: srv.Client(), address: srv.URL, token: "s.root"}, mount: "secret", field: "value"}
+		_, err := denied.Get(ctx, "db/password")
+		assert.ErrorContains(t, err, "403 Forbidden: permission denied")
+	})
+}
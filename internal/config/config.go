@@ -2,9 +2,12 @@ package config
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/BurntSushi/toml"
 
@@ -17,6 +20,10 @@ type Config struct {
 	Stores         map[string]*StoreDefinition `toml:"stores"`
 	DefaultProfile string                      `toml:"default_profile"`
 	Profiles       map[string]*profile.Profile `toml:"profiles"`
+
+	mu sync.Mutex
+	// built holds the stores built by Backend, by name
+	built map[string]backend.Store
 }
 
 func parse(decoder *toml.Decoder) (*Config, error) {
@@ -37,6 +44,9 @@ func parse(decoder *toml.Decoder) (*Config, error) {
 			return nil, err
 		}
 		def.builder = b
+		if def.Cache != nil && def.Cache.TTL <= 0 {
+			return nil, fmt.Errorf("cache of store %s needs a positive ttl", name)
+		}
 	}
 
 	return config, nil
@@ -56,6 +66,8 @@ func ParseFile(path string) (*Config, error) {
 	return parse(toml.NewDecoder(fp))
 }
 
+// Backend returns the store name, building it on first use.
+// Built stores are kept until the config is closed.
 func (c *Config) Backend(ctx context.Context, name string) (backend.Store, error) {
 	// System store is a special OSStore used to store system secrets
 	if name == backend.SystemStoreNameSpace {
@@ -71,7 +83,79 @@ func (c *Config) Backend(ctx context.Context, name string) (backend.Store, error
 		return nil, fmt.Errorf("%s store not found in configuration", name)
 	}
 
-	return def.builder.Build(ctx, name)
+	// Held while building, as building may prompt the user
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if store, ok := c.built[name]; ok {
+		return store, nil
+	}
+
+	store, err := def.builder.Build(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if def.Cache != nil {
+		cache, err := backend.OpenCache(ctx, def.Cache.Backend)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("open cache of %s: %w", name, err), closeStore(store))
+		}
+		store = backend.NewCachedStore(store, cache, name, def.Cache.TTL)
+	}
+
+	if c.built == nil {
+		c.built = make(map[string]backend.Store)
+	}
+	c.built[name] = store
+	return store, nil
+}
+
+// Close closes the stores built by Backend.
+func (c *Config) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var errs []error
+	for name, store := range c.built {
+		if err := closeStore(store); err != nil {
+			errs = append(errs, fmt.Errorf("close %s store: %w", name, err))
+		}
+	}
+	c.built = nil
+	return errors.Join(errs...)
+}
+
+func closeStore(store backend.Store) error {
+	if c, ok := store.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// ClearCache drops the cached secrets of the named stores, or of every cached store if none is given.
+func (c *Config) ClearCache(ctx context.Context, names ...string) error {
+	if len(names) == 0 {
+		for name, def := range c.Stores {
+			if def.Cache != nil {
+				names = append(names, name)
+			}
+		}
+	}
+	for _, name := range names {
+		def, ok := c.Stores[name]
+		if !ok {
+			return fmt.Errorf("%s store not found in configuration", name)
+		}
+		if def.Cache == nil {
+			return fmt.Errorf("%s store is not cached", name)
+		}
+		cache, err := backend.OpenCache(ctx, def.Cache.Backend)
+		if err != nil {
+			return fmt.Errorf("open cache of %s: %w", name, err)
+		}
+		if err := backend.ClearCache(ctx, cache, name); err != nil {
+			return fmt.Errorf("clear cache of %s: %w", name, err)
+		}
+	}
+	return nil
 }
 
 func (c *Config) Profile(name string) (*profile.Profile, error) {
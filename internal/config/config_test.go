@@ -1,12 +1,16 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/b4nst/clef/internal/backend"
 )
 
 func TestConfig_Parse(t *testing.T) {
@@ -34,6 +38,46 @@ func TestConfig_Parse(t *testing.T) {
 		}
 	})
 
+	t.Run("plugin config", func(t *testing.T) {
+		t.Parallel()
+		conf := `
+		 	[stores.acme]
+		 	type = "plugin"
+		 	[stores.acme.config]
+		 	endpoint = "https://secrets.internal"
+
+		 	[stores.other]
+		 	type = "plugin"
+		 `
+		c, err := Parse(conf)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, &backend.PluginStoreBuilder{Config: map[string]any{"endpoint": "https://secrets.internal"}}, c.Stores["acme"].Builder())
+			assert.Equal(t, &backend.PluginStoreBuilder{Config: map[string]any{}}, c.Stores["other"].Builder())
+		}
+	})
+
+	t.Run("cache", func(t *testing.T) {
+		t.Parallel()
+		conf := `
+		 	[stores.gcp]
+		 	type = "gcp"
+		 	cache = { ttl = "10m", backend = "file" }
+		 `
+		c, err := Parse(conf)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, &CacheDefinition{TTL: 10 * time.Minute, Backend: "file"}, c.Stores["gcp"].Cache)
+		}
+
+		_, err = Parse(`
+		 	[stores.gcp]
+		 	type = "gcp"
+		 	cache = { backend = "file" }
+		 `)
+		assert.EqualError(t, err, "cache of store gcp needs a positive ttl")
+	})
+
 	t.Run("missing type", func(t *testing.T) {
 		t.Parallel()
 		conf := `
@@ -48,3 +92,43 @@ func TestConfig_Parse(t *testing.T) {
 		assert.EqualError(t, err, "missing type for store file")
 	})
 }
+
+func TestConfig_ClearCache(t *testing.T) {
+	c, err := Parse(`
+		[stores.gcp]
+		type = "gcp"
+	`)
+	require.NoError(t, err)
+
+	assert.EqualError(t, c.ClearCache(context.TODO(), "gcp"), "gcp store is not cached")
+	assert.EqualError(t, c.ClearCache(context.TODO(), "aws"), "aws store not found in configuration")
+	assert.NoError(t, c.ClearCache(context.TODO()), "nothing to clear")
+}
+
+func TestConfig_Backend(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "configbackend_store")
+	require.NoError(t, err)
+	c, err := Parse(fmt.Sprintf(`
+		default_store = "file"
+		[stores.file]
+		type = "filestore"
+		[stores.file.config]
+		path = "%s"
+	`, f.Name()))
+	require.NoError(t, err)
+
+	store, err := c.Backend(context.TODO(), "file")
+	require.NoError(t, err)
+	again, err := c.Backend(context.TODO(), "default")
+	require.NoError(t, err)
+	assert.Same(t, store, again, "stores are built once")
+
+	require.NoError(t, c.Close())
+	rebuilt, err := c.Backend(context.TODO(), "file")
+	require.NoError(t, err)
+	assert.NotSame(t, store, rebuilt, "closed stores are forgotten")
+	require.NoError(t, c.Close())
+
+	_, err = c.Backend(context.TODO(), "missing")
+	assert.EqualError(t, err, "missing store not found in configuration")
+}
@@ -1,17 +1,28 @@
 package config
 
 import (
+	"time"
+
 	"github.com/BurntSushi/toml"
 	"github.com/b4nst/clef/internal/backend"
 )
 
 type StoreDefinition struct {
-	Type   string         `toml:"type"`
-	Config toml.Primitive `toml:"config"`
+	Type   string           `toml:"type"`
+	Config toml.Primitive   `toml:"config"`
+	Cache  *CacheDefinition `toml:"cache,omitempty"`
 
 	builder backend.Builder
 }
 
+// CacheDefinition enables the read-through cache of a store.
+type CacheDefinition struct {
+	// TTL is how long values are cached.
+	TTL time.Duration `toml:"ttl"`
+	// Backend is where values are cached, system (default) or file.
+	Backend string `toml:"backend,omitempty"`
+}
+
 func (sd *StoreDefinition) Builder() backend.Builder {
 	return sd.builder
 }
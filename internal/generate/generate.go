@@ -0,0 +1,158 @@
+// Package generate creates random secrets: passwords, passphrases, random bytes, UUIDs and key pairs.
+// Randomness always comes from [crypto/rand].
+package generate
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/rand"
+	_ "embed"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/ssh"
+)
+
+// Charsets are the named character sets of passwords.
+var Charsets = map[string]string{
+	"alnum":   "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789",
+	"alpha":   "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+	"digits":  "0123456789",
+	"symbols": "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789!#$%&()*+,-./:;<=>?@[]^_{|}~",
+}
+
+//go:embed bip39.txt
+var bip39 string
+
+// DefaultWordlist is the BIP39 English list of 2048 words, about 11 bits of entropy per word.
+var DefaultWordlist = strings.Fields(bip39)
+
+// randomIndex returns a uniform random integer in [0, n).
+func randomIndex(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(i.Int64()), nil
+}
+
+// Password returns length characters picked at random from charset,
+// either a name of [Charsets] or the characters themselves.
+func Password(length int, charset string) (string, error) {
+	if length <= 0 {
+		return "", errors.New("length must be positive")
+	}
+	if named, ok := Charsets[charset]; ok {
+		charset = named
+	}
+	chars := []rune(charset)
+	if len(chars) == 0 {
+		return "", errors.New("empty charset")
+	}
+
+	var b strings.Builder
+	for range length {
+		i, err := randomIndex(len(chars))
+		if err != nil {
+			return "", err
+		}
+		b.WriteRune(chars[i])
+	}
+	return b.String(), nil
+}
+
+// Passphrase returns words picked at random from wordlist, joined by separator.
+func Passphrase(words int, wordlist []string, separator string) (string, error) {
+	if words <= 0 {
+		return "", errors.New("length must be positive")
+	}
+	if len(wordlist) < 2 {
+		return "", errors.New("wordlist needs at least two words")
+	}
+
+	picked := make([]string, words)
+	for n := range picked {
+		i, err := randomIndex(len(wordlist))
+		if err != nil {
+			return "", err
+		}
+		picked[n] = wordlist[i]
+	}
+	return strings.Join(picked, separator), nil
+}
+
+// ReadWordlist reads one word per line from r, skipping blank lines and duplicates.
+func ReadWordlist(r io.Reader) ([]string, error) {
+	seen := make(map[string]bool)
+	var words []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		w := strings.TrimSpace(scanner.Text())
+		if w == "" || seen[w] {
+			continue
+		}
+		seen[w] = true
+		words = append(words, w)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return words, nil
+}
+
+// Bytes returns n random bytes.
+func Bytes(n int) ([]byte, error) {
+	if n <= 0 {
+		return nil, errors.New("length must be positive")
+	}
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// UUID returns a random (version 4) UUID.
+func UUID() (string, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// SSHKey returns a new ed25519 private key in the OpenSSH format, and its public key in the authorized_keys format.
+func SSHKey(comment string) (private []byte, public string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, "", err
+	}
+	block, err := ssh.MarshalPrivateKey(priv, comment)
+	if err != nil {
+		return nil, "", fmt.Errorf("marshal ssh private key: %w", err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil, "", err
+	}
+	public = strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(sshPub)), "\n")
+	if comment != "" {
+		public += " " + comment
+	}
+	return pem.EncodeToMemory(block), public, nil
+}
+
+// AgeKey returns a new age X25519 identity, and its recipient.
+func AgeKey() (identity, recipient string, err error) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		return "", "", err
+	}
+	return id.String(), id.Recipient().String(), nil
+}
@@ -0,0 +1,124 @@
+package generate
+
+import (
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestPassword(t *testing.T) {
+	t.Parallel()
+
+	t.Run("named charset", func(t *testing.T) {
+		t.Parallel()
+
+		p, err := Password(64, "digits")
+		require.NoError(t, err)
+		assert.Len(t, p, 64)
+		assert.Empty(t, strings.Trim(p, Charsets["digits"]))
+	})
+
+	t.Run("custom charset", func(t *testing.T) {
+		t.Parallel()
+
+		p, err := Password(16, "ab€")
+		require.NoError(t, err)
+		assert.Equal(t, 16, len([]rune(p)))
+		assert.Empty(t, strings.Trim(p, "ab€"))
+	})
+
+	t.Run("random", func(t *testing.T) {
+		t.Parallel()
+
+		a, err := Password(32, "alnum")
+		require.NoError(t, err)
+		b, err := Password(32, "alnum")
+		require.NoError(t, err)
+		assert.NotEqual(t, a, b)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Password(0, "alnum")
+		assert.EqualError(t, err, "length must be positive")
+		_, err = Password(8, "")
+		assert.EqualError(t, err, "empty charset")
+	})
+}
+
+func TestPassphrase(t *testing.T) {
+	t.Parallel()
+
+	require.Len(t, DefaultWordlist, 2048)
+
+	p, err := Passphrase(6, DefaultWordlist, "-")
+	require.NoError(t, err)
+	words := strings.Split(p, "-")
+	assert.Len(t, words, 6)
+	for _, w := range words {
+		assert.Contains(t, DefaultWordlist, w)
+	}
+
+	_, err = Passphrase(6, []string{"only"}, "-")
+	assert.EqualError(t, err, "wordlist needs at least two words")
+}
+
+func TestReadWordlist(t *testing.T) {
+	t.Parallel()
+
+	words, err := ReadWordlist(strings.NewReader("correct\n\nhorse \r\nbattery\nstaple\nhorse\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"correct", "horse", "battery", "staple"}, words)
+}
+
+func TestBytes(t *testing.T) {
+	t.Parallel()
+
+	b, err := Bytes(32)
+	require.NoError(t, err)
+	assert.Len(t, b, 32)
+
+	_, err = Bytes(-1)
+	assert.EqualError(t, err, "length must be positive")
+}
+
+func TestUUID(t *testing.T) {
+	t.Parallel()
+
+	id, err := UUID()
+	require.NoError(t, err)
+	parsed, err := uuid.Parse(id)
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Version(4), parsed.Version())
+}
+
+func TestSSHKey(t *testing.T) {
+	t.Parallel()
+
+	private, public, err := SSHKey("deploy@ci")
+	require.NoError(t, err)
+
+	signer, err := ssh.ParsePrivateKey(private)
+	require.NoError(t, err)
+	pub, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(public))
+	require.NoError(t, err)
+	assert.Equal(t, "deploy@ci", comment)
+	assert.Equal(t, signer.PublicKey().Marshal(), pub.Marshal())
+}
+
+func TestAgeKey(t *testing.T) {
+	t.Parallel()
+
+	identity, recipient, err := AgeKey()
+	require.NoError(t, err)
+
+	id, err := age.ParseX25519Identity(identity)
+	require.NoError(t, err)
+	assert.Equal(t, recipient, id.Recipient().String())
+}
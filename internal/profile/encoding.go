@@ -0,0 +1,24 @@
+package profile
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// Encode returns value encoded as base64, base64url (URL safe alphabet) or hex.
+// An empty encoding returns value as is.
+func Encode(value []byte, encoding string) (string, error) {
+	switch encoding {
+	case "":
+		return string(value), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString(value), nil
+	case "base64url":
+		return base64.URLEncoding.EncodeToString(value), nil
+	case "hex":
+		return hex.EncodeToString(value), nil
+	default:
+		return "", fmt.Errorf("unsupported encoding '%s'", encoding)
+	}
+}
@@ -0,0 +1,65 @@
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrFieldNotFound = errors.New("field not found")
+
+// ExtractField returns the field of the JSON document value.
+//
+// The field is either a JSON pointer (RFC 6901) like /credentials/password, or a dotted path like credentials.password,
+// where numeric segments index arrays. Strings are returned as is, other values as JSON, and null as an empty string.
+func ExtractField(value, field string) (string, error) {
+	var segments []string
+	if pointer, ok := strings.CutPrefix(field, "/"); ok {
+		for _, seg := range strings.Split(pointer, "/") {
+			segments = append(segments, strings.NewReplacer("~1", "/", "~0", "~").Replace(seg))
+		}
+	} else if field != "" {
+		segments = strings.Split(field, ".")
+	}
+
+	dec := json.NewDecoder(strings.NewReader(value))
+	dec.UseNumber()
+	var node any
+	if err := dec.Decode(&node); err != nil {
+		return "", fmt.Errorf("secret is not a JSON document: %w", err)
+	}
+
+	for _, seg := range segments {
+		switch n := node.(type) {
+		case map[string]any:
+			v, ok := n[seg]
+			if !ok {
+				return "", fmt.Errorf("field %s: %w", field, ErrFieldNotFound)
+			}
+			node = v
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(n) {
+				return "", fmt.Errorf("field %s: %w", field, ErrFieldNotFound)
+			}
+			node = n[i]
+		default:
+			return "", fmt.Errorf("field %s: %w", field, ErrFieldNotFound)
+		}
+	}
+
+	switch v := node.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+}
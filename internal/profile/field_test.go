@@ -0,0 +1,78 @@
+package profile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractField(t *testing.T) {
+	t.Parallel()
+
+	const doc = `{"user":"admin","port":5432,"tls":true,"token":null,"hosts":["a","b"],"nested":{"a/b":{"c~d":"x"}},"opts":{"k":"v"}}`
+	tcs := map[string]struct {
+		field string
+		want  string
+	}{
+		"string":         {"user", "admin"},
+		"number":         {"port", "5432"},
+		"bool":           {"tls", "true"},
+		"null":           {"token", ""},
+		"index":          {"hosts.1", "b"},
+		"array":          {"hosts", `["a","b"]`},
+		"object":         {"opts", `{"k":"v"}`},
+		"pointer":        {"/hosts/0", "a"},
+		"pointer escape": {"/nested/a~1b/c~0d", "x"},
+		"whole":          {"", doc},
+	}
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ExtractField(doc, tc.field)
+			require.NoError(t, err)
+			if tc.field == "" {
+				assert.JSONEq(t, tc.want, got)
+				return
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		t.Parallel()
+
+		for _, field := range []string{"password", "hosts.2", "hosts.x", "user.name", "/nested/a/b"} {
+			_, err := ExtractField(doc, field)
+			assert.ErrorIs(t, err, ErrFieldNotFound, field)
+		}
+	})
+
+	t.Run("not json", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := ExtractField("plain", "user")
+		assert.ErrorContains(t, err, "secret is not a JSON document")
+	})
+}
+
+func TestEncode(t *testing.T) {
+	t.Parallel()
+
+	value := []byte{0x00, 0xfb, 0xff}
+	tcs := map[string]string{
+		"":          string(value),
+		"base64":    "APv/",
+		"base64url": "APv_",
+		"hex":       "00fbff",
+	}
+	for encoding, want := range tcs {
+		got, err := Encode(value, encoding)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, encoding)
+	}
+
+	_, err := Encode(value, "base32")
+	assert.EqualError(t, err, "unsupported encoding 'base32'")
+}
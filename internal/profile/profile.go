@@ -2,9 +2,13 @@ package profile
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"slices"
+	"sync"
 	"syscall"
 
 	"github.com/b4nst/clef/internal/backend"
@@ -19,15 +23,136 @@ type Profile struct {
 	Secrets []Secret `toml:"secrets"`
 }
 
+// loadWorkers bounds the number of secrets fetched concurrently.
+const loadWorkers = 8
+
 // Load processes all secrets in the profile, loading and injecting them using the provided function.
 func (p *Profile) Load(ctx context.Context, injectf Injector, loader backend.StoreLoader) error {
-	for _, s := range p.Secrets {
-		if err := s.Inject(ctx, injectf, loader); err != nil {
-			return fmt.Errorf("load %s: %w", s.Key, err)
+	return Load(ctx, injectf, loader, p.Secrets...)
+}
+
+// Load fetches the secrets and injects them in order, using the provided function.
+//
+// Each store is loaded once, one after the other as loading may prompt the user.
+// Secrets are then fetched concurrently, in a single batch per store implementing [backend.BatchGetter],
+// and injected once they are all fetched.
+// Load doesn't stop at the first failure, it returns every error joined.
+func Load(ctx context.Context, injectf Injector, loader backend.StoreLoader, secrets ...Secret) error {
+	var errs []error
+
+	stores := make(map[string]backend.Store)
+	for _, s := range secrets {
+		if _, ok := stores[s.Store]; ok {
+			continue
+		}
+		store, err := loader.Backend(ctx, s.Store)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("load store '%s': %w", s.Store, err))
+			store = nil
+		}
+		stores[s.Store] = store
+	}
+
+	values := make([]string, len(secrets))
+	fetchErrs := make([]error, len(secrets))
+	var jobs []func()
+	// Secrets of stores able to batch are fetched together, by store
+	type batchKey struct {
+		store   string
+		noCache bool
+	}
+	type batch struct {
+		getter  backend.BatchGetter
+		secrets []int
+	}
+	batches := make(map[batchKey]*batch)
+	// Secrets are resolved on a copy, leaving the caller's untouched
+	secrets = slices.Clone(secrets)
+	for i := range secrets {
+		s := &secrets[i]
+		store := stores[s.Store]
+		if store == nil {
+			// Already reported
+			continue
+		}
+		if s.NoCache {
+			store = backend.Uncached(store)
 		}
+		s.resolve(store)
+		// Pinned versions and binary secrets are fetched one by one. So are the secrets of cached stores
+		// wrapping a store unable to batch: the cache misses are then fetched by the workers.
+		_, batching := backend.Uncached(store).(backend.BatchGetter)
+		if bg, ok := store.(backend.BatchGetter); ok && batching && s.Version == "" && s.Encoding == "" {
+			bk := batchKey{s.Store, s.NoCache}
+			if batches[bk] == nil {
+				batches[bk] = &batch{getter: bg}
+			}
+			batches[bk].secrets = append(batches[bk].secrets, i)
+			continue
+		}
+		jobs = append(jobs, func() {
+			values[i], fetchErrs[i] = s.Fetch(ctx, store)
+		})
+	}
+	for _, b := range batches {
+		jobs = append(jobs, func() {
+			var keys []string
+			for _, i := range b.secrets {
+				if !slices.Contains(keys, secrets[i].Key) {
+					keys = append(keys, secrets[i].Key)
+				}
+			}
+			got, err := b.getter.BatchGet(ctx, keys)
+			var batchErr backend.BatchError
+			errors.As(err, &batchErr)
+			for _, i := range b.secrets {
+				k := secrets[i].Key
+				v, ok := got[k]
+				switch {
+				case ok:
+					values[i] = v
+				case batchErr[k] != nil:
+					fetchErrs[i] = batchErr[k]
+				case err != nil && batchErr == nil:
+					fetchErrs[i] = err
+				default:
+					fetchErrs[i] = backend.ErrKeyNotFound
+				}
+			}
+		})
+	}
+
+	sem := make(chan struct{}, loadWorkers)
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			job()
+		}()
 	}
+	wg.Wait()
 
-	return nil
+	for i, s := range secrets {
+		if stores[s.Store] == nil {
+			continue
+		}
+		if fetchErrs[i] != nil {
+			errs = append(errs, fmt.Errorf("load %s: get %s: %w", s.Key, s.Key, fetchErrs[i]))
+			continue
+		}
+		v, err := s.Format(values[i])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("load %s: %w", s.Key, err))
+			continue
+		}
+		target := firstNonEmptyOrDefault(s.Key, s.Target)
+		if err := injectf(target, v); err != nil {
+			errs = append(errs, fmt.Errorf("load %s: inject %s: %w", s.Key, target, err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // Activate replaces the current process with a shell after injecting all secrets.
@@ -50,12 +175,15 @@ func (p *Profile) Activate(ctx context.Context, shell string, stores backend.Sto
 		return nil
 	}
 
-	if err := p.Load(ctx, injector, stores); err != nil {
+	secrets := append(slices.Clone(p.Secrets), additionalSecrets...)
+	if err := Load(ctx, injector, stores, secrets...); err != nil {
 		return fmt.Errorf("load profile: %w", err)
 	}
-	for _, s := range additionalSecrets {
-		if err := s.Inject(ctx, injector, stores); err != nil {
-			return fmt.Errorf("load secret: %w", err)
+
+	// The process is replaced, stores must be closed now
+	if c, ok := stores.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			return fmt.Errorf("close stores: %w", err)
 		}
 	}
 
@@ -80,14 +208,10 @@ func (p *Profile) Exec(ctx context.Context, args []string, stores backend.StoreL
 		return nil
 	}
 
-	if err := p.Load(ctx, injector, stores); err != nil {
+	secrets := append(slices.Clone(p.Secrets), additionalSecrets...)
+	if err := Load(ctx, injector, stores, secrets...); err != nil {
 		return fmt.Errorf("load profile: %w", err)
 	}
-	for _, s := range additionalSecrets {
-		if err := s.Inject(ctx, injector, stores); err != nil {
-			return fmt.Errorf("load secret: %w", err)
-		}
-	}
 
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -0,0 +1,243 @@
+package profile
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/b4nst/clef/internal/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	t.Run("one load per store", func(t *testing.T) {
+		var injected [][2]string
+		injector := func(k, v string) error {
+			injected = append(injected, [2]string{k, v})
+			return nil
+		}
+
+		gcp := backend.NewMockStore(t)
+		gcp.EXPECT().Get(mock.Anything, "a").Return("1", nil).Twice()
+		gcp.EXPECT().Get(mock.Anything, "b").Return("2", nil).Once()
+		aws := backend.NewMockStore(t)
+		aws.EXPECT().Get(mock.Anything, "c").Return("3", nil).Once()
+		loader := backend.NewMockStoreLoader(t)
+		loader.EXPECT().Backend(mock.Anything, "gcp").Return(gcp, nil).Once()
+		loader.EXPECT().Backend(mock.Anything, "aws").Return(aws, nil).Once()
+
+		err := Load(context.TODO(), injector, loader,
+			Secret{Key: "a", Store: "gcp"},
+			Secret{Key: "c", Store: "aws", Target: "C"},
+			Secret{Key: "b", Store: "gcp"},
+			Secret{Key: "a", Store: "gcp", Target: "A"},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, [][2]string{{"a", "1"}, {"C", "3"}, {"b", "2"}, {"A", "1"}}, injected, "secrets are injected in order")
+	})
+
+	t.Run("concurrent fetch", func(t *testing.T) {
+		wrappers := map[string]func(backend.Store) backend.Store{
+			"store": func(s backend.Store) backend.Store { return s },
+			"cached store": func(s backend.Store) backend.Store {
+				cache, err := backend.NewFileStore(filepath.Join(t.TempDir(), "cache"))
+				require.NoError(t, err)
+				return backend.NewCachedStore(s, cache, "test", time.Hour)
+			},
+		}
+		for name, wrap := range wrappers {
+			t.Run(name, func(t *testing.T) {
+				var mu sync.Mutex
+				var running, peak atomic.Int32
+				store := backend.NewMockStore(t)
+				store.EXPECT().Get(mock.Anything, mock.Anything).RunAndReturn(func(context.Context, string) (string, error) {
+					n := running.Add(1)
+					defer running.Add(-1)
+					mu.Lock()
+					if n > peak.Load() {
+						peak.Store(n)
+					}
+					mu.Unlock()
+					time.Sleep(10 * time.Millisecond)
+					return "v", nil
+				})
+				loader := backend.NewMockStoreLoader(t)
+				loader.EXPECT().Backend(mock.Anything, "").Return(wrap(store), nil).Once()
+
+				secrets := make([]Secret, 3*loadWorkers)
+				for i := range secrets {
+					secrets[i] = Secret{Key: string(rune('a' + i))}
+				}
+				require.NoError(t, Load(context.TODO(), func(k, v string) error { return nil }, loader, secrets...))
+				assert.Greater(t, peak.Load(), int32(1))
+				assert.LessOrEqual(t, peak.Load(), int32(loadWorkers))
+			})
+		}
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		injected := map[string]string{}
+		injector := func(k, v string) error {
+			injected[k] = v
+			return nil
+		}
+
+		getter := backend.NewMockBatchGetter(t)
+		getter.EXPECT().BatchGet(mock.Anything, []string{"a", "b", "missing", "denied"}).Return(
+			map[string]string{"a": "1", "b": "2"},
+			backend.BatchError{"denied": errors.New("access denied")},
+		).Once()
+		store := struct {
+			backend.Store
+			backend.BatchGetter
+		}{backend.NewMockStore(t), getter}
+		loader := backend.NewMockStoreLoader(t)
+		loader.EXPECT().Backend(mock.Anything, "aws").Return(store, nil).Once()
+
+		err := Load(context.TODO(), injector, loader,
+			Secret{Key: "a", Store: "aws"},
+			Secret{Key: "b", Store: "aws"},
+			Secret{Key: "a", Store: "aws", Target: "A"},
+			Secret{Key: "missing", Store: "aws"},
+			Secret{Key: "denied", Store: "aws"},
+		)
+		assert.EqualError(t, err, "load missing: get missing: key not found\n"+
+			"load denied: get denied: access denied")
+		assert.Equal(t, map[string]string{"a": "1", "b": "2", "A": "1"}, injected)
+	})
+
+	t.Run("batch failure", func(t *testing.T) {
+		getter := backend.NewMockBatchGetter(t)
+		getter.EXPECT().BatchGet(mock.Anything, []string{"a", "b"}).Return(nil, errors.New("throttled")).Once()
+		store := struct {
+			backend.Store
+			backend.BatchGetter
+		}{backend.NewMockStore(t), getter}
+		loader := backend.NewMockStoreLoader(t)
+		loader.EXPECT().Backend(mock.Anything, "aws").Return(store, nil).Once()
+
+		err := Load(context.TODO(), func(k, v string) error { return nil }, loader,
+			Secret{Key: "a", Store: "aws"},
+			Secret{Key: "b", Store: "aws"},
+		)
+		assert.EqualError(t, err, "load a: get a: throttled\nload b: get b: throttled")
+	})
+
+	t.Run("all errors", func(t *testing.T) {
+		injectErr := errors.New("inject")
+		injector := func(k, v string) error {
+			if k == "bad-target" {
+				return injectErr
+			}
+			return nil
+		}
+
+		store := backend.NewMockStore(t)
+		store.EXPECT().Get(mock.Anything, "missing").Return("", backend.ErrKeyNotFound).Once()
+		store.EXPECT().Get(mock.Anything, "ok").Return("v", nil).Twice()
+		loader := backend.NewMockStoreLoader(t)
+		loader.EXPECT().Backend(mock.Anything, "good").Return(store, nil).Once()
+		loader.EXPECT().Backend(mock.Anything, "broken").Return(nil, errors.New("no credentials")).Once()
+
+		err := Load(context.TODO(), injector, loader,
+			Secret{Key: "ok", Store: "good"},
+			Secret{Key: "missing", Store: "good"},
+			Secret{Key: "x", Store: "broken"},
+			Secret{Key: "y", Store: "broken"},
+			Secret{Key: "ok", Store: "good", Target: "bad-target"},
+		)
+		assert.ErrorIs(t, err, backend.ErrKeyNotFound)
+		assert.ErrorIs(t, err, injectErr)
+		assert.EqualError(t, err, "load store 'broken': no credentials\n"+
+			"load missing: get missing: key not found\n"+
+			"load ok: inject bad-target: inject")
+	})
+
+	t.Run("fields", func(t *testing.T) {
+		injected := map[string]string{}
+		injector := func(k, v string) error {
+			injected[k] = v
+			return nil
+		}
+
+		getter := backend.NewMockBatchGetter(t)
+		getter.EXPECT().BatchGet(mock.Anything, []string{"db"}).Return(map[string]string{"db": `{"user":"admin","password":"s3cr3t"}`}, nil).Once()
+		store := struct {
+			backend.Store
+			backend.BatchGetter
+		}{backend.NewMockStore(t), getter}
+		loader := backend.NewMockStoreLoader(t)
+		loader.EXPECT().Backend(mock.Anything, "aws").Return(store, nil).Once()
+
+		err := Load(context.TODO(), injector, loader,
+			Secret{Key: "db", Store: "aws", Field: "user", Target: "DB_USER"},
+			Secret{Key: "db", Store: "aws", Field: "/password", Target: "DB_PASSWORD"},
+			Secret{Key: "db", Store: "aws", Field: "host", Target: "DB_HOST"},
+		)
+		assert.ErrorIs(t, err, ErrFieldNotFound)
+		assert.EqualError(t, err, "load db: field host: field not found")
+		assert.Equal(t, map[string]string{"DB_USER": "admin", "DB_PASSWORD": "s3cr3t"}, injected)
+	})
+
+	t.Run("versions", func(t *testing.T) {
+		injected := map[string]string{}
+		injector := func(k, v string) error {
+			injected[k] = v
+			return nil
+		}
+
+		batch := backend.NewMockBatchGetter(t)
+		batch.EXPECT().BatchGet(mock.Anything, []string{"db"}).Return(map[string]string{"db": "current"}, nil).Once()
+		versions := backend.NewMockVersionGetter(t)
+		versions.EXPECT().GetVersion(mock.Anything, "db", "AWSPREVIOUS").Return("previous", nil).Once()
+		store := struct {
+			backend.Store
+			backend.BatchGetter
+			backend.VersionGetter
+		}{backend.NewMockStore(t), batch, versions}
+		plain := backend.NewMockStore(t)
+		loader := backend.NewMockStoreLoader(t)
+		loader.EXPECT().Backend(mock.Anything, "aws").Return(store, nil).Once()
+		loader.EXPECT().Backend(mock.Anything, "os").Return(plain, nil).Once()
+
+		err := Load(context.TODO(), injector, loader,
+			Secret{Key: "db", Store: "aws", Target: "CURRENT"},
+			Secret{Key: "db", Store: "aws", Version: "AWSPREVIOUS", Target: "PREVIOUS"},
+			Secret{Key: "db", Store: "os", Version: "1"},
+		)
+		assert.ErrorIs(t, err, backend.ErrUnversioned)
+		assert.Equal(t, map[string]string{"CURRENT": "current", "PREVIOUS": "previous"}, injected)
+	})
+
+	t.Run("encoding", func(t *testing.T) {
+		injected := map[string]string{}
+		injector := func(k, v string) error {
+			injected[k] = v
+			return nil
+		}
+
+		plain := backend.NewMockStore(t)
+		plain.EXPECT().Get(mock.Anything, "keystore").Return("\x00\xff", nil).Once()
+		// Binary secrets are not batched
+		store := struct {
+			backend.Store
+			backend.BatchGetter
+		}{plain, backend.NewMockBatchGetter(t)}
+		loader := backend.NewMockStoreLoader(t)
+		loader.EXPECT().Backend(mock.Anything, "aws").Return(store, nil).Once()
+
+		err := Load(context.TODO(), injector, loader,
+			Secret{Key: "keystore", Store: "aws", Encoding: "base64", Target: "KEYSTORE"},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"KEYSTORE": "AP8="}, injected)
+	})
+}
@@ -3,6 +3,7 @@ package profile
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/alecthomas/kong"
 	"github.com/b4nst/clef/internal/backend"
@@ -25,6 +26,17 @@ type Secret struct {
 	Store string `toml:"store,omitempty"`
 	// Target is the name to use when injecting the secret (defaults to Key if empty)
 	Target string `toml:"target,omitempty"`
+	// Version pins the version of the secret, for stores keeping versions (optional, defaults to the current one)
+	Version string `toml:"version,omitempty"`
+	// Field is the field to extract from a JSON secret, as a dotted path or a JSON pointer (optional)
+	Field string `toml:"field,omitempty"`
+	// Encoding encodes the value before injecting it, as base64, base64url or hex, e.g. for binary secrets (optional)
+	Encoding string `toml:"encoding,omitempty"`
+	// NoCache always reads the secret from the store, bypassing its cache
+	NoCache bool `toml:"no-cache,omitempty"`
+
+	// inlineVersion is set when Version comes from the @ of a reference, which keys may contain too
+	inlineVersion bool
 }
 
 // Decode implements a custom mapper for kong.
@@ -39,44 +51,62 @@ func (s *Secret) Decode(ktx *kong.DecodeContext) error {
 	return s.DecodeText(text)
 }
 
-// DecodeText decode a [Secret] from a string.
+// DecodeText decode a [Secret] from a string, formatted as [store.]key[@version][#field][=target].
 func (s *Secret) DecodeText(text string) error {
-	keyStart := 0
-	keyEnd := len(text)
-	targetStart := -1
-	storeEnd := -1
-
-	// Scan from right to left
-	for i := len(text) - 1; i >= 0; i-- {
-		if targetStart == -1 && text[i] == '=' {
-			// Found the first '=' from the right
-			keyEnd = i
-			targetStart = i + 1
-		} else if text[i] == '.' && storeEnd == -1 {
-			// Found the first '.' from the right before any '='
-			storeEnd = i
-			keyStart = i + 1
-			// We can break early as we've found all we need
-			break
-		}
+	ref := text
+	if i := strings.LastIndexByte(ref, '='); i >= 0 {
+		ref, s.Target = ref[:i], ref[i+1:]
+	}
+	// The field may contain dots, it is split first
+	if key, field, ok := strings.Cut(ref, "#"); ok {
+		ref, s.Field = key, field
+	}
+	if i := strings.LastIndexByte(ref, '.'); i >= 0 {
+		s.Store, ref = ref[:i], ref[i+1:]
+	}
+	if i := strings.LastIndexByte(ref, '@'); i >= 0 {
+		ref, s.Version, s.inlineVersion = ref[:i], ref[i+1:], true
 	}
 
-	s.Key = text[keyStart:keyEnd]
+	s.Key = ref
 	// Ensure Key is not empty
 	if s.Key == "" {
 		return ErrEmptyKey
 	}
 
-	// Extract the components
-	if storeEnd != -1 {
-		s.Store = text[:storeEnd]
+	return nil
+}
+
+// resolve binds the secret to its store: on stores without versions, the @version of a reference
+// is part of the key, e.g. bitwarden.github@work reads the key github@work.
+func (s *Secret) resolve(store backend.Store) {
+	if !s.inlineVersion {
+		return
+	}
+	if _, ok := backend.Uncached(store).(backend.VersionGetter); !ok {
+		s.Key, s.Version = s.Key+"@"+s.Version, ""
 	}
+	s.inlineVersion = false
+}
 
-	if targetStart != -1 {
-		s.Target = text[targetStart:]
+// Fetch returns the raw value of the secret from store, at its version if pinned.
+func (s *Secret) Fetch(ctx context.Context, store backend.Store) (string, error) {
+	if s.Version != "" {
+		return backend.GetVersion(ctx, store, s.Key, s.Version)
 	}
+	v, err := backend.GetBytes(ctx, store, s.Key)
+	return string(v), err
+}
 
-	return nil
+// Format returns the value to inject from the raw value v, extracting its field and encoding it.
+func (s *Secret) Format(v string) (string, error) {
+	if s.Field != "" {
+		var err error
+		if v, err = ExtractField(v, s.Field); err != nil {
+			return "", err
+		}
+	}
+	return Encode([]byte(v), s.Encoding)
 }
 
 // Inject loads a secret from the specified store and injects it using the provided function.
@@ -86,11 +116,18 @@ func (s *Secret) Inject(ctx context.Context, injectf Injector, loader backend.St
 	if err != nil {
 		return fmt.Errorf("load store '%s': %w", s.Store, err)
 	}
+	if s.NoCache {
+		store = backend.Uncached(store)
+	}
+	s.resolve(store)
 
-	plain, err := store.Get(ctx, s.Key)
+	plain, err := s.Fetch(ctx, store)
 	if err != nil {
 		return fmt.Errorf("get %s: %w", s.Key, err)
 	}
+	if plain, err = s.Format(plain); err != nil {
+		return err
+	}
 
 	// default target to key
 	if s.Target == "" {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/b4nst/clef/internal/backend"
 	"github.com/stretchr/testify/assert"
@@ -35,6 +36,68 @@ func TestSecret_Inject(t *testing.T) {
 		assert.Equal(t, "bar", system["foo"])
 	})
 
+	t.Run("bypass cache", func(t *testing.T) {
+		system := map[string]string{}
+		injector := func(k, v string) error {
+			system[k] = v
+			return nil
+		}
+
+		store := backend.NewMockStore(t)
+		store.EXPECT().Get(mock.Anything, "foo").Return("bar", nil).Once()
+		// The cache must not be read
+		cache := backend.NewMockStore(t)
+		loader := backend.NewMockStoreLoader(t)
+		loader.EXPECT().Backend(context.TODO(), "default").Return(backend.NewCachedStore(store, cache, "default", time.Hour), nil).Once()
+
+		secret := Secret{Key: "foo", Store: "default", NoCache: true}
+		require.NoError(t, secret.Inject(context.TODO(), injector, loader))
+		assert.Equal(t, "bar", system["foo"])
+	})
+
+	t.Run("field", func(t *testing.T) {
+		system := map[string]string{}
+		injector := func(k, v string) error {
+			system[k] = v
+			return nil
+		}
+
+		store := backend.NewMockStore(t)
+		store.EXPECT().Get(mock.Anything, "creds").Return(`{"user":"admin","password":"s3cr3t"}`, nil).Once()
+		loader := backend.NewMockStoreLoader(t)
+		loader.EXPECT().Backend(context.TODO(), "default").Return(store, nil).Once()
+
+		secret := Secret{Key: "creds", Store: "default", Field: "password", Target: "PASSWORD"}
+		require.NoError(t, secret.Inject(context.TODO(), injector, loader))
+		assert.Equal(t, map[string]string{"PASSWORD": "s3cr3t"}, system)
+	})
+
+	t.Run("at in key", func(t *testing.T) {
+		system := map[string]string{}
+		injector := func(k, v string) error {
+			system[k] = v
+			return nil
+		}
+
+		store := backend.NewMockStore(t)
+		store.EXPECT().Get(mock.Anything, "github@work").Return("ghp_1", nil).Once()
+		getter := backend.NewMockVersionGetter(t)
+		getter.EXPECT().GetVersion(mock.Anything, "db", "3").Return("old", nil).Once()
+		loader := backend.NewMockStoreLoader(t)
+		loader.EXPECT().Backend(context.TODO(), "bitwarden").Return(store, nil).Once()
+		loader.EXPECT().Backend(context.TODO(), "gcp").Return(struct {
+			backend.Store
+			backend.VersionGetter
+		}{backend.NewMockStore(t), getter}, nil).Once()
+
+		for _, ref := range []string{"bitwarden.github@work=TOKEN", "gcp.db@3=DB"} {
+			var secret Secret
+			require.NoError(t, secret.DecodeText(ref))
+			require.NoError(t, secret.Inject(context.TODO(), injector, loader))
+		}
+		assert.Equal(t, map[string]string{"TOKEN": "ghp_1", "DB": "old"}, system, "@ is a version on versioned stores only")
+	})
+
 	t.Run("load store failure", func(t *testing.T) {
 		therr := errors.New("oops")
 		loader := backend.NewMockStoreLoader(t)
@@ -77,11 +140,17 @@ func TestSecret_UnmarshalText(t *testing.T) {
 		out Secret
 		err error
 	}{
-		"nostore":  {"key=target", Secret{"key", "", "target"}, nil},
-		"notarget": {"store.key", Secret{"key", "store", ""}, nil},
-		"keyonly":  {"key", Secret{"key", "", ""}, nil},
-		"all":      {"store.key=target", Secret{"key", "store", "target"}, nil},
+		"nostore":  {"key=target", Secret{Key: "key", Target: "target"}, nil},
+		"notarget": {"store.key", Secret{Key: "key", Store: "store"}, nil},
+		"keyonly":  {"key", Secret{Key: "key"}, nil},
+		"all":      {"store.key=target", Secret{Key: "key", Store: "store", Target: "target"}, nil},
+		"field":    {"store.key#a.b=target", Secret{Key: "key", Store: "store", Field: "a.b", Target: "target"}, nil},
+		"pointer":  {"key#/a/b", Secret{Key: "key", Field: "/a/b"}, nil},
+		"dotstore": {"my.store.key", Secret{Key: "key", Store: "my.store"}, nil},
+		"path":     {"sops.database/password=DB_PASSWORD", Secret{Key: "database/password", Store: "sops", Target: "DB_PASSWORD"}, nil},
+		"version":  {"aws.db@AWSPREVIOUS#password=PASS", Secret{Key: "db", Store: "aws", Version: "AWSPREVIOUS", Field: "password", Target: "PASS", inlineVersion: true}, nil},
 		"empty":    {"", Secret{}, ErrEmptyKey},
+		"nokey":    {"store.#field", Secret{Store: "store", Field: "field"}, ErrEmptyKey},
 	}
 
 	for name, tc := range tcs {